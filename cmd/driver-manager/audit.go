@@ -0,0 +1,261 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	corev1 "k8s.io/api/core/v1"
+
+	kube "github.com/NVIDIA/k8s-driver-manager/internal/kubernetes"
+)
+
+const (
+	// pausedAtAnnotation records when driver-manager paused the GPU operator components of a node
+	pausedAtAnnotation = nvidiaDomainPrefix + "/" + "gpu-driver-upgrade-paused-at"
+	// configDigestAnnotation records the driver configuration digest last rolled out to a node
+	configDigestAnnotation = nvidiaDomainPrefix + "/" + "gpu-driver-config-digest"
+
+	auditReasonPaused       = "Paused"
+	auditReasonCordoned     = "Cordoned"
+	auditReasonTainted      = "Tainted"
+	auditReasonStaleDigest  = "StaleConfigDigest"
+	auditReasonPreInstalled = "PreInstalled"
+)
+
+// auditFinding is a reason for which a node has reduced GPU capacity
+type auditFinding struct {
+	Reason string `json:"reason"`
+	Detail string `json:"detail"`
+	// Since is when the node entered this state, if known
+	Since *time.Time `json:"since,omitempty"`
+}
+
+// nodeAudit lists the findings of a single node
+type nodeAudit struct {
+	Node     string         `json:"node"`
+	Findings []auditFinding `json:"findings"`
+}
+
+func newAuditCommand(cfg *config, components *componentState, log *logrus.Logger) *cli.Command {
+	var selector, output, configDigest string
+	return &cli.Command{
+		Name:  "audit",
+		Usage: "List the nodes of the cluster left paused, cordoned or out of date by driver upgrades",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "selector",
+				Aliases:     []string{"l"},
+				Usage:       "Only audit the nodes matching this label selector",
+				Destination: &selector,
+			},
+			&cli.StringFlag{
+				Name:        "config-digest",
+				Usage:       "The desired driver configuration digest; nodes last upgraded to a different digest are reported as stale",
+				Destination: &configDigest,
+				EnvVars:     []string{"DRIVER_CONFIG_DIGEST"},
+			},
+			&cli.StringFlag{
+				Name:        "output",
+				Aliases:     []string{"o"},
+				Usage:       "Output format, one of: table, json",
+				Destination: &output,
+				Value:       statusOutputTable,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			dm, err := newDriverManager(c.Context, cfg, components, log)
+			if err != nil {
+				return fmt.Errorf("failed to create driver manager: %w", err)
+			}
+			nodes, err := dm.kubeClient.ListNodes(selector)
+			if err != nil {
+				return err
+			}
+			var audits []nodeAudit
+			for i := range nodes {
+				if audit := dm.auditNode(&nodes[i], configDigest); len(audit.Findings) > 0 {
+					audits = append(audits, audit)
+				}
+			}
+			return printAudit(os.Stdout, audits, output, time.Now())
+		},
+	}
+}
+
+// auditNode reports the paused operand labels, driver-manager cordon or taint, stale driver config
+// digest and pre-installed driver of a node. The time a node entered each state is taken from
+// the annotations written by driver-manager, falling back to the managed fields of the node.
+func (dm *DriverManager) auditNode(node *corev1.Node, configDigest string) nodeAudit {
+	audit := nodeAudit{Node: node.Name}
+
+	var pausedLabels []string
+	var pausedSince *time.Time
+	for label, value := range node.Labels {
+		if !dm.isOperandLabel(label) || !strings.Contains(value, pausedStr) {
+			continue
+		}
+		pausedLabels = append(pausedLabels, label)
+		if since, ok := kube.FieldLastSetTime(node, "metadata", "labels", label); ok && (pausedSince == nil || since.Before(*pausedSince)) {
+			pausedSince = &since
+		}
+	}
+	if len(pausedLabels) > 0 {
+		sort.Strings(pausedLabels)
+		if since := annotationTime(node, pausedAtAnnotation); since != nil {
+			pausedSince = since
+		}
+		audit.Findings = append(audit.Findings, auditFinding{
+			Reason: auditReasonPaused,
+			Detail: strings.Join(pausedLabels, ","),
+			Since:  pausedSince,
+		})
+	}
+
+	if ownership := getCordonOwnership(node.Annotations); node.Spec.Unschedulable && ownership != nil {
+		audit.Findings = append(audit.Findings, auditFinding{
+			Reason: auditReasonCordoned,
+			Detail: fmt.Sprintf("cordoned by driver-manager: %s", ownership.Reason),
+			Since:  &ownership.Timestamp,
+		})
+	}
+
+	for _, taint := range node.Spec.Taints {
+		if taint.Key != quiesceTaintKey {
+			continue
+		}
+		since := fieldLastSetTime(node, "spec", "taints")
+		if taint.TimeAdded != nil {
+			since = &taint.TimeAdded.Time
+		}
+		audit.Findings = append(audit.Findings, auditFinding{
+			Reason: auditReasonTainted,
+			Detail: fmt.Sprintf("tainted by driver-manager: %s", taint.ToString()),
+			Since:  since,
+		})
+	}
+
+	if digest := node.Annotations[configDigestAnnotation]; configDigest != "" && digest != "" && digest != configDigest {
+		audit.Findings = append(audit.Findings, auditFinding{
+			Reason: auditReasonStaleDigest,
+			Detail: fmt.Sprintf("driver config digest %s, desired %s", digest, configDigest),
+			Since:  fieldLastSetTime(node, "metadata", "annotations", configDigestAnnotation),
+		})
+	}
+
+	if node.Labels[nvidiaDriverDeployLabel] == "pre-installed" {
+		audit.Findings = append(audit.Findings, auditFinding{
+			Reason: auditReasonPreInstalled,
+			Detail: fmt.Sprintf("%s=pre-installed", nvidiaDriverDeployLabel),
+			Since:  fieldLastSetTime(node, "metadata", "labels", nvidiaDriverDeployLabel),
+		})
+	}
+
+	return audit
+}
+
+// isOperandLabel reports whether a label is a GPU operator component deploy label or the
+// custom operand node selector label
+func (dm *DriverManager) isOperandLabel(label string) bool {
+	if strings.HasPrefix(label, operandDeployLabelPrefix) {
+		return true
+	}
+	return dm.config.nodeLabelForGPUPodEviction != "" && label == dm.config.nodeLabelForGPUPodEviction
+}
+
+func annotationTime(node *corev1.Node, annotation string) *time.Time {
+	value, ok := node.Annotations[annotation]
+	if !ok {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+func fieldLastSetTime(node *corev1.Node, fieldPath ...string) *time.Time {
+	t, ok := kube.FieldLastSetTime(node, fieldPath...)
+	if !ok {
+		return nil
+	}
+	return &t
+}
+
+// printAudit writes the audit findings in the given output format
+func printAudit(w io.Writer, audits []nodeAudit, output string, now time.Time) error {
+	switch output {
+	case statusOutputJSON:
+		if audits == nil {
+			audits = []nodeAudit{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(audits)
+	case statusOutputTable:
+	default:
+		return fmt.Errorf("unsupported output format %q", output)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "NODE\tREASON\tSINCE\tAGE\tDETAIL\n")
+	for _, audit := range audits {
+		for _, finding := range audit.Findings {
+			since, age := "<unknown>", "<unknown>"
+			if finding.Since != nil {
+				since = finding.Since.UTC().Format(time.RFC3339)
+				age = now.Sub(*finding.Since).Truncate(time.Minute).String()
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", audit.Node, finding.Reason, since, age, finding.Detail)
+		}
+	}
+	return tw.Flush()
+}
+
+// recordDriverState records the driver configuration digest rolled out to the node, along
+// with the boot of the node it is rolled out in
+func (dm *DriverManager) recordDriverState() {
+	dm.recordBootID()
+	digest := os.Getenv("DRIVER_CONFIG_DIGEST")
+	if digest == "" {
+		return
+	}
+	node, err := dm.nodeSnapshot()
+	if err == nil {
+		err = dm.updateNodeMetadata(node, func(node *kube.NodeSnapshot) (map[string]string, map[string]*string, error) {
+			if node.Annotations[configDigestAnnotation] == digest {
+				return nil, nil, nil
+			}
+			return nil, map[string]*string{configDigestAnnotation: &digest}, nil
+		})
+	}
+	if err != nil {
+		dm.log.Warnf("Failed to record the driver config digest on node %s: %v", dm.config.nodeName, err)
+	}
+}
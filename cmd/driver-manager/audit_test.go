@@ -0,0 +1,127 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAuditNode(t *testing.T) {
+	pausedAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	labeledAt := metav1.NewTime(time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC))
+
+	testCases := []struct {
+		description      string
+		node             *corev1.Node
+		expectedFindings []auditFinding
+	}{
+		{
+			description: "healthy node",
+			node:        newTestNode(map[string]string{nvidiaDevicePluginDeployLabel: "true"}, map[string]string{configDigestAnnotation: testConfigDigest}),
+		},
+		{
+			description: "paused since annotation",
+			node: newTestNode(
+				map[string]string{nvidiaDevicePluginDeployLabel: pausedStr, nvidiaGFDDeployLabel: "true_" + pausedStr},
+				map[string]string{pausedAtAnnotation: pausedAt.Format(time.RFC3339)},
+			),
+			expectedFindings: []auditFinding{{
+				Reason: auditReasonPaused,
+				Detail: nvidiaDevicePluginDeployLabel + "," + nvidiaGFDDeployLabel,
+				Since:  &pausedAt,
+			}},
+		},
+		{
+			description: "cordoned by another actor",
+			node: func() *corev1.Node {
+				node := newTestNode(nil, nil)
+				node.Spec.Unschedulable = true
+				return node
+			}(),
+		},
+		{
+			description: "cordoned by driver-manager with a stale digest",
+			node: func() *corev1.Node {
+				node := newTestNode(nil, map[string]string{
+					cordonOwnerAnnotation:  `{"reason":"upgrade","timestamp":"2026-10-01T12:00:00Z"}`,
+					configDigestAnnotation: "digest-0",
+				})
+				node.Spec.Unschedulable = true
+				return node
+			}(),
+			expectedFindings: []auditFinding{
+				{Reason: auditReasonCordoned, Detail: "cordoned by driver-manager: upgrade", Since: &pausedAt},
+				{Reason: auditReasonStaleDigest, Detail: "driver config digest digest-0, desired " + testConfigDigest},
+			},
+		},
+		{
+			description: "pre-installed since managed fields",
+			node: func() *corev1.Node {
+				node := newTestNode(map[string]string{nvidiaDriverDeployLabel: "pre-installed"}, nil)
+				node.ManagedFields = []metav1.ManagedFieldsEntry{{
+					Manager:  "driver-manager",
+					Time:     &labeledAt,
+					FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:labels":{"f:nvidia.com/gpu.deploy.driver":{}}}}`)},
+				}}
+				return node
+			}(),
+			expectedFindings: []auditFinding{{
+				Reason: auditReasonPreInstalled,
+				Detail: nvidiaDriverDeployLabel + "=pre-installed",
+				Since:  &labeledAt.Time,
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			dm := newTestDriverManager(t, fake.NewClientset(), newFakeHost(), nil)
+			audit := dm.auditNode(tc.node, testConfigDigest)
+			require.Equal(t, testNodeName, audit.Node)
+			require.Equal(t, tc.expectedFindings, audit.Findings)
+		})
+	}
+}
+
+func TestAuditCommand(t *testing.T) {
+	t.Setenv("NODE_NAME", "")
+	t.Setenv("DRIVER_CONFIG_DIGEST", "")
+
+	requests, err := runTestApp(t, []corev1.Node{*newTestNode(defaultTestOperandLabels(), nil)}, "audit", "--output", "json")
+	require.NoError(t, err)
+	require.Equal(t, []string{"GET /api/v1/nodes"}, requests)
+}
+
+func TestNodeCommandsRequireNodeName(t *testing.T) {
+	t.Setenv("NODE_NAME", "")
+
+	for _, command := range []string{"uninstall_driver", "preflight_check", "status", "wait_for_driver_and_reschedule"} {
+		t.Run(command, func(t *testing.T) {
+			requests, err := runTestApp(t, nil, command)
+			require.ErrorContains(t, err, "the node-name flag or the NODE_NAME environment variable must be set")
+			require.Empty(t, requests)
+		})
+	}
+}
@@ -0,0 +1,130 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	kube "github.com/NVIDIA/k8s-driver-manager/internal/kubernetes"
+)
+
+// autoscalerProtectionAnnotation records the annotations protecting the node from autoscaler
+// scale-down added by driver-manager, as a JSON object, so that only those are removed
+const autoscalerProtectionAnnotation = nvidiaDomainPrefix + "/" + "gpu-driver-upgrade-autoscaler-protection"
+
+// defaultAutoscalerProtectionAnnotations keep cluster-autoscaler and Karpenter from deleting
+// a node which looks empty and underutilized while its driver is upgraded
+var defaultAutoscalerProtectionAnnotations = []string{
+	"cluster-autoscaler.kubernetes.io/scale-down-disabled=true",
+	"karpenter.sh/do-not-disrupt=true",
+}
+
+// parseAutoscalerProtectionAnnotations parses annotations given as key=value pairs. A key
+// without a value is set to "true".
+func parseAutoscalerProtectionAnnotations(values []string) (map[string]string, error) {
+	annotations := make(map[string]string)
+	for _, value := range values {
+		key, annotationValue, found := strings.Cut(value, "=")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("invalid autoscaler protection annotation %q", value)
+		}
+		if !found {
+			annotationValue = "true"
+		}
+		annotations[key] = annotationValue
+	}
+	return annotations, nil
+}
+
+// getAutoscalerProtection returns the autoscaler protection annotations added by driver-manager
+// to the node
+func (dm *DriverManager) getAutoscalerProtection(node *kube.NodeSnapshot) map[string]string {
+	added := make(map[string]string)
+	value, ok := node.Annotations[autoscalerProtectionAnnotation]
+	if !ok {
+		return added
+	}
+	if err := json.Unmarshal([]byte(value), &added); err != nil {
+		dm.log.Warnf("Ignoring invalid %s annotation on node %s: %v", autoscalerProtectionAnnotation, node.Name, err)
+		return make(map[string]string)
+	}
+	return added
+}
+
+// protectFromScaleDown annotates the node so that autoscalers do not delete it during the
+// disruptive part of the driver upgrade. Annotations which are already set, e.g. by an admin,
+// are left as they are and will not be removed by driver-manager.
+func (dm *DriverManager) protectFromScaleDown() error {
+	if len(dm.autoscalerProtection) == 0 {
+		return nil
+	}
+
+	node, err := dm.nodeSnapshot()
+	if err != nil {
+		return err
+	}
+	return dm.updateNodeMetadata(node, func(node *kube.NodeSnapshot) (map[string]string, map[string]*string, error) {
+		added := dm.getAutoscalerProtection(node)
+		annotations := make(map[string]*string)
+		for key, value := range dm.autoscalerProtection {
+			if _, ok := node.Annotations[key]; ok {
+				continue
+			}
+			annotations[key] = ptr(value)
+			added[key] = value
+		}
+		if len(annotations) == 0 {
+			return nil, nil, nil
+		}
+
+		record, err := json.Marshal(added)
+		if err != nil {
+			return nil, nil, err
+		}
+		annotations[autoscalerProtectionAnnotation] = ptr(string(record))
+		dm.log.Infof("Protecting node %s from autoscaler scale-down during the driver upgrade", node.Name)
+		return nil, annotations, nil
+	})
+}
+
+// unprotectFromScaleDown removes the autoscaler protection annotations driver-manager added to
+// a node. Annotations changed by someone else since are left as they are.
+func (dm *DriverManager) unprotectFromScaleDown(nodeName string) error {
+	node, err := dm.getNodeSnapshot(nodeName)
+	if err != nil {
+		return err
+	}
+	return dm.updateNodeMetadata(node, func(node *kube.NodeSnapshot) (map[string]string, map[string]*string, error) {
+		if _, ok := node.Annotations[autoscalerProtectionAnnotation]; !ok {
+			return nil, nil, nil
+		}
+
+		annotations := map[string]*string{autoscalerProtectionAnnotation: nil}
+		for key, value := range dm.getAutoscalerProtection(node) {
+			if current, ok := node.Annotations[key]; ok && current == value {
+				annotations[key] = nil
+			}
+		}
+		dm.log.Infof("Lifting the autoscaler scale-down protection of node %s", node.Name)
+		return nil, annotations, nil
+	})
+}
@@ -0,0 +1,119 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseAutoscalerProtectionAnnotations(t *testing.T) {
+	annotations, err := parseAutoscalerProtectionAnnotations(defaultAutoscalerProtectionAnnotations)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"cluster-autoscaler.kubernetes.io/scale-down-disabled": "true",
+		"karpenter.sh/do-not-disrupt":                          "true",
+	}, annotations)
+
+	annotations, err = parseAutoscalerProtectionAnnotations([]string{"example.com/keep", "example.com/reason=driver-upgrade"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"example.com/keep": "true", "example.com/reason": "driver-upgrade"}, annotations)
+
+	_, err = parseAutoscalerProtectionAnnotations([]string{"=true"})
+	require.Error(t, err)
+}
+
+func TestAutoscalerProtection(t *testing.T) {
+	const (
+		scaleDownDisabled = "cluster-autoscaler.kubernetes.io/scale-down-disabled"
+		doNotDisrupt      = "karpenter.sh/do-not-disrupt"
+	)
+
+	testCases := []struct {
+		description string
+		annotations map[string]string
+		// modify changes the node annotations while it is protected, as an admin would
+		modify              map[string]string
+		expectedProtected   map[string]string
+		expectedUnprotected map[string]string
+	}{
+		{
+			description:         "unprotected node",
+			expectedProtected:   map[string]string{scaleDownDisabled: "true", doNotDisrupt: "true"},
+			expectedUnprotected: map[string]string{},
+		},
+		{
+			description:         "annotation set by an admin",
+			annotations:         map[string]string{doNotDisrupt: "true"},
+			expectedProtected:   map[string]string{scaleDownDisabled: "true", doNotDisrupt: "true"},
+			expectedUnprotected: map[string]string{doNotDisrupt: "true"},
+		},
+		{
+			description:         "annotation changed during the upgrade",
+			modify:              map[string]string{scaleDownDisabled: "false"},
+			expectedProtected:   map[string]string{scaleDownDisabled: "true", doNotDisrupt: "true"},
+			expectedUnprotected: map[string]string{scaleDownDisabled: "false"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			clientset := fake.NewClientset(newTestNode(nil, tc.annotations))
+			dm := newTestDriverManager(t, clientset, newFakeHost(), nil)
+			dm.autoscalerProtection = map[string]string{scaleDownDisabled: "true", doNotDisrupt: "true"}
+
+			protectionAnnotations := func() map[string]string {
+				node, err := clientset.CoreV1().Nodes().Get(context.Background(), testNodeName, metav1.GetOptions{})
+				require.NoError(t, err)
+				result := make(map[string]string)
+				for _, key := range []string{scaleDownDisabled, doNotDisrupt} {
+					if value, ok := node.Annotations[key]; ok {
+						result[key] = value
+					}
+				}
+				return result
+			}
+
+			require.NoError(t, dm.protectFromScaleDown())
+			require.Equal(t, tc.expectedProtected, protectionAnnotations())
+
+			if tc.modify != nil {
+				node, err := clientset.CoreV1().Nodes().Get(context.Background(), testNodeName, metav1.GetOptions{})
+				require.NoError(t, err)
+				for key, value := range tc.modify {
+					node.Annotations[key] = value
+				}
+				_, err = clientset.CoreV1().Nodes().Update(context.Background(), node, metav1.UpdateOptions{FieldManager: "kubectl-edit"})
+				require.NoError(t, err)
+				dm.invalidateNodeSnapshot(testNodeName)
+			}
+
+			require.NoError(t, dm.unprotectFromScaleDown(testNodeName))
+			require.Equal(t, tc.expectedUnprotected, protectionAnnotations())
+
+			node, err := clientset.CoreV1().Nodes().Get(context.Background(), testNodeName, metav1.GetOptions{})
+			require.NoError(t, err)
+			require.NotContains(t, node.Annotations, autoscalerProtectionAnnotation)
+		})
+	}
+}
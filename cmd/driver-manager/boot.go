@@ -0,0 +1,109 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// readBootID returns the ID of the current boot of the kernel of the node
+func (dm *DriverManager) readBootID() (string, error) {
+	data, err := dm.host.readFile(dm.procPath("sys", "kernel", "random", "boot_id"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the boot ID: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// recordBootID records the current boot of the node in the boot ID state file
+func (dm *DriverManager) recordBootID() {
+	if dm.bootID == "" {
+		return
+	}
+	if err := dm.host.writeFile(dm.bootIDStateFile(), []byte(dm.bootID+"\n")); err != nil {
+		dm.log.Warnf("Failed to record the boot ID of node %s: %v", dm.config.nodeName, err)
+	}
+}
+
+// reconcileBoot compares the current boot of the node with the one recorded by driver-manager.
+// An upgrade checkpoint left by a previous boot is stale, since the driver it was unloading
+// is gone, so it is discarded and the paused GPU operator components are restored. An upgrade
+// checkpoint from the current boot is left by a restart of the driver container, and the
+// upgrade is resumed from it.
+func (dm *DriverManager) reconcileBoot() error {
+	bootID, err := dm.readBootID()
+	if err != nil {
+		dm.log.Warnf("Unable to tell a reboot of node %s from a restart: %v", dm.config.nodeName, err)
+		return nil
+	}
+	dm.bootID = bootID
+
+	node, err := dm.nodeSnapshot()
+	if err != nil {
+		return err
+	}
+	_, checkpoint := node.Annotations[pausedLabelsAnnotation]
+	data, err := dm.host.readFile(dm.bootIDStateFile())
+	if err != nil && !os.IsNotExist(err) {
+		dm.log.Warnf("Unable to tell a reboot of node %s from a restart: %v", node.Name, err)
+		return nil
+	}
+	recorded := strings.TrimSpace(string(data))
+
+	switch {
+	case recorded == "" && !checkpoint:
+		return nil
+	case recorded == bootID:
+		if checkpoint {
+			dm.log.Infof("Driver container restarted mid-upgrade on node %s, resuming the upgrade", node.Name)
+			dm.operandsPaused = true
+		}
+		return nil
+	}
+
+	dm.rebooted = true
+	if recorded == "" {
+		// The boot ID is recorded before pausing the GPU operator components, so the run
+		// directory holding it was cleared by a reboot
+		recorded = "unknown"
+	}
+	dm.log.Infof("Node %s rebooted since driver-manager last ran (boot %s, previously %s), the driver upgrade is reboot-driven", node.Name, bootID, recorded)
+	if err := dm.clearRebootRequirement(node); err != nil {
+		return fmt.Errorf("failed to clear the reboot requirement: %w", err)
+	}
+	if node, err = dm.nodeSnapshot(); err != nil {
+		return err
+	}
+	if !checkpoint {
+		return nil
+	}
+
+	dm.log.Infof("Discarding the upgrade checkpoint left on node %s by the previous boot and restoring the GPU operator components", node.Name)
+	restored, err := dm.resumeOperands(node)
+	if err != nil {
+		return fmt.Errorf("failed to restore the GPU operator components paused before the reboot: %w", err)
+	}
+	dm.log.Infof("Restored %d GPU operator component label(s) paused before the reboot", restored)
+	if err := dm.unprotectFromScaleDown(node.Name); err != nil {
+		return err
+	}
+	return dm.unquiesceNodeAndEndMaintenance(node.Name)
+}
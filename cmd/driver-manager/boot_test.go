@@ -0,0 +1,137 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReconcileBoot(t *testing.T) {
+	const (
+		currentBootID  = "3f9c5b1e-0c4e-4e8a-9d6f-2b7a1c8e5d40"
+		previousBootID = "a1b2c3d4-0000-4000-8000-000000000000"
+	)
+	checkpoint := map[string]string{
+		pausedLabelsAnnotation: `{"` + nvidiaDevicePluginDeployLabel + `":"true"}`,
+		cordonOwnerAnnotation:  `{"reason":"evicting GPU pods for a driver upgrade"}`,
+	}
+	testCases := []struct {
+		description            string
+		noBootID               bool
+		recordedBootID         string
+		annotations            map[string]string
+		expectedRebooted       bool
+		expectedOperandsPaused bool
+		expectedLabel          string
+	}{
+		{
+			description:   "first run",
+			expectedLabel: pausedStr,
+		},
+		{
+			description:    "boot ID unavailable",
+			noBootID:       true,
+			recordedBootID: previousBootID,
+			annotations:    checkpoint,
+			expectedLabel:  pausedStr,
+		},
+		{
+			description:            "restart mid-upgrade",
+			recordedBootID:         currentBootID,
+			annotations:            checkpoint,
+			expectedOperandsPaused: true,
+			expectedLabel:          pausedStr,
+		},
+		{
+			description:      "reboot mid-upgrade",
+			recordedBootID:   previousBootID,
+			annotations:      checkpoint,
+			expectedRebooted: true,
+			expectedLabel:    "true",
+		},
+		{
+			description:      "reboot clearing the run directory mid-upgrade",
+			annotations:      checkpoint,
+			expectedRebooted: true,
+			expectedLabel:    "true",
+		},
+		{
+			description:      "reboot after an upgrade",
+			recordedBootID:   previousBootID,
+			expectedRebooted: true,
+			expectedLabel:    pausedStr,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			node := newTestNode(map[string]string{nvidiaDevicePluginDeployLabel: pausedStr}, tc.annotations)
+			node.Spec.Unschedulable = tc.annotations[cordonOwnerAnnotation] != ""
+			clientset := fake.NewClientset(node)
+			h := newFakeHost()
+			if !tc.noBootID {
+				h.files["/proc/sys/kernel/random/boot_id"] = currentBootID + "\n"
+			}
+			dm := newTestDriverManager(t, clientset, h, nil)
+			if tc.recordedBootID != "" {
+				h.files[dm.bootIDStateFile()] = tc.recordedBootID + "\n"
+			}
+
+			require.NoError(t, dm.reconcileBoot())
+			require.Equal(t, tc.expectedRebooted, dm.rebooted)
+			require.Equal(t, tc.expectedOperandsPaused, dm.operandsPaused)
+
+			node, err := clientset.CoreV1().Nodes().Get(context.Background(), testNodeName, metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedLabel, node.Labels[nvidiaDevicePluginDeployLabel])
+			if tc.expectedRebooted && tc.annotations[pausedLabelsAnnotation] != "" {
+				require.NotContains(t, node.Annotations, pausedLabelsAnnotation)
+				require.NotContains(t, node.Annotations, cordonOwnerAnnotation)
+				require.False(t, node.Spec.Unschedulable)
+			}
+		})
+	}
+}
+
+func TestRecordBootIDBeforePausing(t *testing.T) {
+	const bootID = "3f9c5b1e-0c4e-4e8a-9d6f-2b7a1c8e5d40"
+
+	clientset := fake.NewClientset(newTestNode(defaultTestOperandLabels(), nil))
+	h := newFakeHost()
+	h.files["/proc/sys/kernel/random/boot_id"] = bootID + "\n"
+	dm := newTestDriverManager(t, clientset, h, nil)
+	require.NoError(t, dm.reconcileBoot())
+
+	require.NoError(t, dm.pauseOperands([]string{nvidiaDevicePluginDeployLabel}))
+	require.Equal(t, bootID+"\n", h.files[dm.bootIDStateFile()])
+	node, err := clientset.CoreV1().Nodes().Get(context.Background(), testNodeName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, pausedStr, node.Labels[nvidiaDevicePluginDeployLabel])
+
+	// A restart of the driver container resumes the upgrade
+	dm = newTestDriverManager(t, clientset, h, nil)
+	require.NoError(t, dm.reconcileBoot())
+	require.False(t, dm.rebooted)
+	require.True(t, dm.operandsPaused)
+}
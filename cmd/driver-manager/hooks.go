@@ -0,0 +1,51 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/NVIDIA/k8s-driver-manager/internal/hooks"
+)
+
+// runHooks runs the user-supplied hooks registered for a phase of the driver upgrade
+func (dm *DriverManager) runHooks(phase hooks.Phase) error {
+	if dm.hookRunner == nil {
+		return nil
+	}
+
+	payload := hooks.Payload{
+		Node:                 dm.config.nodeName,
+		Phase:                phase,
+		CurrentDriverVersion: dm.loadedDriverVersion(),
+		DesiredDriverVersion: dm.config.driverVersion,
+		DriverConfigDigest:   os.Getenv("DRIVER_CONFIG_DIGEST"),
+	}
+	return dm.hookRunner.Run(dm.ctx, payload)
+}
+
+// loadedDriverVersion returns the version of the loaded NVIDIA kernel module, if any
+func (dm *DriverManager) loadedDriverVersion() string {
+	data, err := dm.host.readFile(dm.sysfsPath("module", "nvidia", "version"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,77 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/moby/sys/mount"
+	"golang.org/x/sys/unix"
+)
+
+// host abstracts the filesystem and kernel operations the DriverManager performs on the node
+type host interface {
+	pathExists(path string) bool
+	readFile(path string) ([]byte, error)
+	readDir(path string) ([]os.DirEntry, error)
+	writeFile(path string, data []byte) error
+	removeFile(path string) error
+	deleteModule(name string) error
+	recursiveUnmount(path string) error
+	runCommand(name string, args ...string) ([]byte, error)
+}
+
+// linuxHost performs the host operations against the local system
+type linuxHost struct{}
+
+var _ host = (*linuxHost)(nil)
+
+func (linuxHost) pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func (linuxHost) readFile(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+func (linuxHost) readDir(path string) ([]os.DirEntry, error) {
+	return os.ReadDir(path)
+}
+
+func (linuxHost) writeFile(path string, data []byte) error {
+	return os.WriteFile(path, data, 0644)
+}
+
+func (linuxHost) removeFile(path string) error {
+	return os.Remove(path)
+}
+
+func (linuxHost) deleteModule(name string) error {
+	return unix.DeleteModule(name, 0)
+}
+
+func (linuxHost) recursiveUnmount(path string) error {
+	return mount.RecursiveUnmount(path)
+}
+
+func (linuxHost) runCommand(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).Output()
+}
@@ -23,30 +23,31 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
+	"os/signal"
 	"path/filepath"
-	"regexp"
 	"strings"
+	"syscall"
 	"time"
 
-	"github.com/moby/sys/mount"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
-	"golang.org/x/sys/unix"
 
+	"github.com/NVIDIA/k8s-driver-manager/internal/hooks"
 	"github.com/NVIDIA/k8s-driver-manager/internal/info"
 	kube "github.com/NVIDIA/k8s-driver-manager/internal/kubernetes"
 	"github.com/NVIDIA/k8s-driver-manager/internal/linuxutils"
 )
 
 const (
-	driverRoot            = "/run/nvidia/driver"
-	driverPIDFile         = "/run/nvidia/nvidia-driver.pid"
-	driverConfigStateFile = "/run/nvidia/nvidia-driver.state"
-	operatorNamespace     = "gpu-operator"
-	pausedStr             = "paused-for-driver-upgrade"
-	defaultDrainTimeout   = time.Second * 0
-	defaultGracePeriod    = 5 * time.Minute
+	operatorNamespace   = "gpu-operator"
+	pausedStr           = "paused-for-driver-upgrade"
+	defaultDrainTimeout = time.Second * 0
+	defaultGracePeriod  = 5 * time.Minute
+
+	defaultGPUPodEvictionGracePeriodSeconds = -1
+	defaultUpgradeSlotStaleTimeout          = 10 * time.Minute
+	// minUpgradeSlotStaleTimeout leaves the holder of an upgrade slot time to renew it
+	minUpgradeSlotStaleTimeout = 30 * time.Second
 
 	nvidiaDomainPrefix = "nvidia.com"
 
@@ -76,6 +77,7 @@ type config struct {
 	drainPodSelectorLabel      string
 	drainTimeout               time.Duration
 	drainDeleteEmptyDirData    bool
+	drainBackend               string
 	enableAutoDrain            bool
 	enableGPUPodEviction       bool
 	operatorNamespace          string
@@ -84,6 +86,53 @@ type config struct {
 	useHostMofed               bool
 	kubeconfig                 string
 	forceReinstall             bool
+
+	gpuPodEvictionGracePeriodSeconds int
+	gpuPodEvictionNoticePeriod       time.Duration
+	gpuPodEvictionNoticeCondition    bool
+
+	gpuPodEvictionExcludedNamespaces cli.StringSlice
+	gpuPodEvictionRequireOptIn       bool
+	gpuPodEvictionPriorityWait       time.Duration
+
+	gpuResourceNamePatterns         cli.StringSlice
+	gpuPodDetectVisibleDevicesEnv   bool
+	gpuPodDetectDeviceMounts        bool
+	gpuPodCorrelateHostGPUProcesses bool
+
+	maxConcurrentUpgrades   int
+	upgradeSlotStaleTimeout time.Duration
+
+	maintenanceWindowSchedule  string
+	maintenanceWindowTimezone  string
+	maintenanceWindowDuration  string
+	maintenanceWindowConfigMap string
+
+	hooksConfigFile string
+	driverVersion   string
+
+	hostRoot  string
+	sysfsRoot string
+	procRoot  string
+	runDir    string
+
+	quiesceMode        string
+	quiesceTaintEffect string
+
+	shutdownRollbackTimeout time.Duration
+
+	operandTerminationTimeout  time.Duration
+	operandTerminationTimeouts cli.StringSlice
+	upgradeDeadline            time.Duration
+
+	forceLabelOwnership bool
+
+	deferReschedule bool
+
+	rebootOnUnloadFailure bool
+	rebootKeepCordoned    bool
+
+	autoscalerProtectionAnnotations cli.StringSlice
 }
 
 // ComponentState tracks the deployment state of GPU operator components
@@ -115,7 +164,31 @@ type DriverManager struct {
 	config     *config
 	components *componentState
 	kubeClient *kube.Client
+	host       host
 	log        *logrus.Logger
+
+	upgradeSlot *kube.UpgradeSlot
+	hookRunner  *hooks.Runner
+
+	// node is the snapshot of the node the driver manager works from. It is dropped when
+	// driver-manager modifies the node without getting its new state back.
+	node *kube.NodeSnapshot
+
+	// operandsPaused is set while the GPU operator components of the node are paused, so that
+	// they can be rescheduled if driver-manager is terminated mid-upgrade
+	operandsPaused bool
+
+	operandTimeouts     map[string]time.Duration
+	stopUpgradeDeadline func()
+
+	// bootID is the ID of the current boot of the node, and rebooted is set if the node
+	// rebooted since driver-manager last ran
+	bootID   string
+	rebooted bool
+
+	// autoscalerProtection holds the annotations protecting the node from autoscaler
+	// scale-down during the driver upgrade
+	autoscalerProtection map[string]string
 }
 
 func main() {
@@ -128,6 +201,22 @@ func main() {
 	cfg := &config{}
 	components := &componentState{}
 
+	app := newApp(cfg, components, log)
+
+	// Cancel all waits on termination, so that driver-manager can roll back its changes to
+	// the node before the pod is killed
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	if err := app.RunContext(ctx, os.Args); err != nil {
+		stop()
+		log.Fatal(err)
+	}
+}
+
+// newApp returns the driver-manager command line application. The node name is only required
+// by the commands managing the current node, so that cluster-wide commands can run anywhere.
+func newApp(cfg *config, components *componentState, log *logrus.Logger) *cli.App {
 	app := cli.NewApp()
 	app.Name = "driver-manager"
 	app.Usage = "The NVIDIA Driver Manager is a Kubernetes component which assists in the seamless upgrades of NVIDIA  " +
@@ -138,10 +227,9 @@ func main() {
 	app.Flags = []cli.Flag{
 		&cli.StringFlag{
 			Name:        "node-name",
-			Usage:       "The name of the node to manage",
+			Usage:       "The name of the node to manage, required by the commands managing a single node",
 			Destination: &cfg.nodeName,
 			EnvVars:     []string{"NODE_NAME"},
-			Required:    true,
 		},
 		&cli.BoolFlag{
 			Name:        "drain-use-force",
@@ -171,6 +259,13 @@ func main() {
 			EnvVars:     []string{"DRAIN_DELETE_EMPTYDIR_DATA"},
 			Value:       false,
 		},
+		&cli.StringFlag{
+			Name:        "drain-backend",
+			Usage:       "How to drain the node, one of: kubectl, node-maintenance. node-maintenance requests the drain from the medik8s Node Maintenance Operator through a NodeMaintenance resource, which drains the whole node and does not support drain-pod-selector-label, drain-use-force or drain-delete-emptydir-data",
+			Destination: &cfg.drainBackend,
+			EnvVars:     []string{"DRAIN_BACKEND"},
+			Value:       drainBackendKubectl,
+		},
 		&cli.BoolFlag{
 			Name:        "enable-auto-drain",
 			Usage:       "Enable automatic node draining",
@@ -185,6 +280,75 @@ func main() {
 			EnvVars:     []string{"ENABLE_GPU_POD_EVICTION"},
 			Value:       true,
 		},
+		&cli.IntFlag{
+			Name:        "gpu-pod-eviction-grace-period-seconds",
+			Usage:       "Termination grace period in seconds given to evicted GPU pods. A negative value uses the grace period of each pod",
+			Destination: &cfg.gpuPodEvictionGracePeriodSeconds,
+			EnvVars:     []string{"GPU_POD_EVICTION_GRACE_PERIOD_SECONDS"},
+			Value:       defaultGPUPodEvictionGracePeriodSeconds,
+		},
+		&cli.DurationFlag{
+			Name:        "gpu-pod-eviction-notice-period",
+			Usage:       "Time to wait after annotating GPU pods with their eviction deadline before evicting them. Zero disables the notification",
+			Destination: &cfg.gpuPodEvictionNoticePeriod,
+			EnvVars:     []string{"GPU_POD_EVICTION_NOTICE_PERIOD"},
+			Value:       0,
+		},
+		&cli.BoolFlag{
+			Name:        "gpu-pod-eviction-notice-condition",
+			Usage:       "Also add a pod condition to GPU pods when notifying them of their upcoming eviction",
+			Destination: &cfg.gpuPodEvictionNoticeCondition,
+			EnvVars:     []string{"GPU_POD_EVICTION_NOTICE_CONDITION"},
+			Value:       false,
+		},
+		&cli.StringSliceFlag{
+			Name:        "gpu-pod-eviction-excluded-namespaces",
+			Usage:       "Namespaces whose GPU pods are never evicted",
+			Destination: &cfg.gpuPodEvictionExcludedNamespaces,
+			EnvVars:     []string{"GPU_POD_EVICTION_EXCLUDED_NAMESPACES"},
+		},
+		&cli.BoolFlag{
+			Name:        "gpu-pod-eviction-require-opt-in",
+			Usage:       "Only evict GPU pods annotated with " + kube.GPUPodEvictionOptInAnnotation + "=true",
+			Destination: &cfg.gpuPodEvictionRequireOptIn,
+			EnvVars:     []string{"GPU_POD_EVICTION_REQUIRE_OPT_IN"},
+			Value:       false,
+		},
+		&cli.DurationFlag{
+			Name:        "gpu-pod-eviction-priority-wait",
+			Usage:       "Time to wait after evicting the GPU pods of one priority before evicting those of the next higher priority",
+			Destination: &cfg.gpuPodEvictionPriorityWait,
+			EnvVars:     []string{"GPU_POD_EVICTION_PRIORITY_WAIT"},
+			Value:       0,
+		},
+		&cli.StringSliceFlag{
+			Name:        "gpu-resource-name-patterns",
+			Usage:       "Glob patterns of the extended resource names identifying GPU pods, e.g. nvidia.com/pgpu or nvidia.com/*",
+			Destination: &cfg.gpuResourceNamePatterns,
+			EnvVars:     []string{"GPU_RESOURCE_NAME_PATTERNS"},
+			Value:       cli.NewStringSlice(kube.DefaultGPUResourceNamePatterns...),
+		},
+		&cli.BoolFlag{
+			Name:        "gpu-pod-detect-visible-devices-env",
+			Usage:       "Also treat pods setting NVIDIA_VISIBLE_DEVICES in a container environment as GPU pods",
+			Destination: &cfg.gpuPodDetectVisibleDevicesEnv,
+			EnvVars:     []string{"GPU_POD_DETECT_VISIBLE_DEVICES_ENV"},
+			Value:       false,
+		},
+		&cli.BoolFlag{
+			Name:        "gpu-pod-detect-device-mounts",
+			Usage:       "Also treat pods mounting NVIDIA device nodes from the host as GPU pods",
+			Destination: &cfg.gpuPodDetectDeviceMounts,
+			EnvVars:     []string{"GPU_POD_DETECT_DEVICE_MOUNTS"},
+			Value:       false,
+		},
+		&cli.BoolFlag{
+			Name:        "gpu-pod-correlate-host-processes",
+			Usage:       "Also treat pods owning host processes with NVIDIA device nodes open as GPU pods (requires the host PID namespace)",
+			Destination: &cfg.gpuPodCorrelateHostGPUProcesses,
+			EnvVars:     []string{"GPU_POD_CORRELATE_HOST_PROCESSES"},
+			Value:       false,
+		},
 		&cli.StringFlag{
 			Name:        "operator-namespace",
 			Usage:       "Namespace where the GPU operator is installed in",
@@ -213,6 +377,162 @@ func main() {
 			EnvVars:     []string{"USE_HOST_MOFED"},
 			Value:       false,
 		},
+		&cli.IntFlag{
+			Name:        "max-concurrent-upgrades",
+			Usage:       "Maximum number of nodes in the cluster disrupted by a driver upgrade at the same time. Zero disables the limit",
+			Destination: &cfg.maxConcurrentUpgrades,
+			EnvVars:     []string{"MAX_CONCURRENT_UPGRADES"},
+			Value:       0,
+		},
+		&cli.DurationFlag{
+			Name:        "upgrade-slot-stale-timeout",
+			Usage:       "Time after which a driver upgrade slot which has not been renewed by its holder is taken over. Must be at least 30s",
+			Destination: &cfg.upgradeSlotStaleTimeout,
+			EnvVars:     []string{"UPGRADE_SLOT_STALE_TIMEOUT"},
+			Value:       defaultUpgradeSlotStaleTimeout,
+		},
+		&cli.StringFlag{
+			Name:        "maintenance-window-schedule",
+			Usage:       "Cron expression for the start of the maintenance windows in which disruptive driver operations may be performed",
+			Destination: &cfg.maintenanceWindowSchedule,
+			EnvVars:     []string{"MAINTENANCE_WINDOW_SCHEDULE"},
+			Value:       "",
+		},
+		&cli.StringFlag{
+			Name:        "maintenance-window-timezone",
+			Usage:       "IANA timezone in which the maintenance window schedule is evaluated",
+			Destination: &cfg.maintenanceWindowTimezone,
+			EnvVars:     []string{"MAINTENANCE_WINDOW_TIMEZONE"},
+			Value:       "UTC",
+		},
+		&cli.StringFlag{
+			Name:        "maintenance-window-duration",
+			Usage:       "Duration of each maintenance window, e.g. 4h",
+			Destination: &cfg.maintenanceWindowDuration,
+			EnvVars:     []string{"MAINTENANCE_WINDOW_DURATION"},
+			Value:       "",
+		},
+		&cli.StringFlag{
+			Name:        "maintenance-window-configmap",
+			Usage:       "Name of a ConfigMap in the operator namespace with schedule, timezone and duration keys defining the maintenance window",
+			Destination: &cfg.maintenanceWindowConfigMap,
+			EnvVars:     []string{"MAINTENANCE_WINDOW_CONFIGMAP"},
+			Value:       "",
+		},
+		&cli.StringFlag{
+			Name:        "hooks-config",
+			Usage:       "Path to a YAML or JSON file configuring the hooks run at the phases of a driver upgrade",
+			Destination: &cfg.hooksConfigFile,
+			EnvVars:     []string{"HOOKS_CONFIG"},
+			Value:       "",
+		},
+		&cli.StringFlag{
+			Name:        "driver-version",
+			Usage:       "Version of the NVIDIA driver being installed, reported to hooks",
+			Destination: &cfg.driverVersion,
+			EnvVars:     []string{"DRIVER_VERSION"},
+			Value:       "",
+		},
+		&cli.BoolFlag{
+			Name:        "force-label-ownership",
+			Usage:       "Take ownership of node labels and annotations managed by another field manager, e.g. the GPU Operator, when they conflict with those applied by driver-manager. By default such a conflict aborts the driver upgrade",
+			Destination: &cfg.forceLabelOwnership,
+			EnvVars:     []string{"FORCE_LABEL_OWNERSHIP"},
+			Value:       false,
+		},
+		&cli.DurationFlag{
+			Name:        "operand-termination-timeout",
+			Usage:       "Time each GPU operator component is given to shutdown during a driver upgrade",
+			Destination: &cfg.operandTerminationTimeout,
+			EnvVars:     []string{"OPERAND_TERMINATION_TIMEOUT"},
+			Value:       defaultGracePeriod,
+		},
+		&cli.StringSliceFlag{
+			Name:        "operand-termination-timeouts",
+			Usage:       "Per-operand shutdown timeouts overriding operand-termination-timeout, e.g. dcgm-exporter=2m,device-plugin=30s",
+			Destination: &cfg.operandTerminationTimeouts,
+			EnvVars:     []string{"OPERAND_TERMINATION_TIMEOUTS"},
+		},
+		&cli.DurationFlag{
+			Name:        "upgrade-deadline",
+			Usage:       "Abort a driver upgrade which has not completed within this duration after GPU operator components start to be evicted. Zero disables the deadline",
+			Destination: &cfg.upgradeDeadline,
+			EnvVars:     []string{"UPGRADE_DEADLINE"},
+		},
+		&cli.DurationFlag{
+			Name:        "shutdown-rollback-timeout",
+			Usage:       "Time allowed for restoring the operand labels and lifting the cordon of the node when driver-manager is terminated mid-upgrade",
+			Destination: &cfg.shutdownRollbackTimeout,
+			EnvVars:     []string{"SHUTDOWN_ROLLBACK_TIMEOUT"},
+			Value:       defaultShutdownRollbackTimeout,
+		},
+		&cli.StringFlag{
+			Name:        "quiesce-mode",
+			Usage:       "How to keep new pods off the node during a driver upgrade, one of: cordon, taint",
+			Destination: &cfg.quiesceMode,
+			EnvVars:     []string{"QUIESCE_MODE"},
+			Value:       quiesceModeCordon,
+		},
+		&cli.StringFlag{
+			Name:        "quiesce-taint-effect",
+			Usage:       "Effect of the taint applied in the taint quiesce mode, one of: NoSchedule, NoExecute",
+			Destination: &cfg.quiesceTaintEffect,
+			EnvVars:     []string{"QUIESCE_TAINT_EFFECT"},
+			Value:       defaultQuiesceTaintEffect,
+		},
+		&cli.StringSliceFlag{
+			Name:        "autoscaler-protection-annotations",
+			Usage:       "Annotations, as key=value pairs, protecting the node from autoscaler scale-down during the disruptive part of a driver upgrade",
+			Destination: &cfg.autoscalerProtectionAnnotations,
+			EnvVars:     []string{"AUTOSCALER_PROTECTION_ANNOTATIONS"},
+			Value:       cli.NewStringSlice(defaultAutoscalerProtectionAnnotations...),
+		},
+		&cli.BoolFlag{
+			Name:        "defer-reschedule",
+			Usage:       "Leave the rescheduling of the GPU operator components after a driver upgrade to the wait_for_driver_and_reschedule command, which waits for the new driver to be ready",
+			Destination: &cfg.deferReschedule,
+			EnvVars:     []string{"DEFER_RESCHEDULE"},
+		},
+		&cli.BoolFlag{
+			Name:        "reboot-on-unload-failure",
+			Usage:       "Mark the node as requiring a reboot, e.g. for kured, when the NVIDIA driver cannot be unloaded",
+			Destination: &cfg.rebootOnUnloadFailure,
+			EnvVars:     []string{"REBOOT_ON_UNLOAD_FAILURE"},
+		},
+		&cli.BoolFlag{
+			Name:        "reboot-keep-cordoned",
+			Usage:       "Keep a node requiring a reboot cordoned until it is rebooted",
+			Destination: &cfg.rebootKeepCordoned,
+			EnvVars:     []string{"REBOOT_KEEP_CORDONED"},
+		},
+		&cli.StringFlag{
+			Name:        "host-root",
+			Usage:       "Path to the host root filesystem, used to run host binaries",
+			Destination: &cfg.hostRoot,
+			EnvVars:     []string{"HOST_ROOT"},
+			Value:       defaultHostRoot,
+		},
+		&cli.StringFlag{
+			Name:        "sysfs-root",
+			Usage:       "Path to the sysfs of the host",
+			Destination: &cfg.sysfsRoot,
+			EnvVars:     []string{"SYSFS_ROOT"},
+			Value:       defaultSysfsRoot,
+		},
+		&cli.StringFlag{
+			Name:        "proc-root",
+			Usage:       "Path to the procfs of the host",
+			Destination: &cfg.procRoot,
+			EnvVars:     []string{"PROC_ROOT"},
+			Value:       defaultProcRoot,
+		},
+		&cli.StringFlag{
+			Name:        "run-dir",
+			Usage:       "Path to the host runtime directory holding the nvidia and mellanox driver state",
+			Destination: &cfg.runDir,
+			EnvVars:     []string{"RUN_DIR"},
+			Value:       defaultRunDir,
+		},
 		&cli.StringFlag{
 			Name:        "kubeconfig",
 			Usage:       "Path to kubeconfig file",
@@ -231,19 +551,24 @@ func main() {
 
 	app.Commands = []*cli.Command{
 		{
-			Name:  "uninstall_driver",
-			Usage: "Uninstall NVIDIA driver and manage GPU operator components",
+			Name:   "uninstall_driver",
+			Usage:  "Uninstall NVIDIA driver and manage GPU operator components",
+			Before: requireNodeName(cfg),
 			Action: func(c *cli.Context) error {
 				dm, err := newDriverManager(c.Context, cfg, components, log)
 				if err != nil {
 					return fmt.Errorf("failed to create driver manager: %w", err)
 				}
+				if err := dm.checkPermissions(); err != nil {
+					return err
+				}
 				return dm.uninstallDriver()
 			},
 		},
 		{
-			Name:  "preflight_check",
-			Usage: "Perform preflight checks",
+			Name:   "preflight_check",
+			Usage:  "Perform preflight checks",
+			Before: requireNodeName(cfg),
 			Action: func(c *cli.Context) error {
 				dm, err := newDriverManager(c.Context, cfg, components, log)
 				if err != nil {
@@ -252,10 +577,22 @@ func main() {
 				return dm.preflightCheck()
 			},
 		},
+		newStatusCommand(cfg, components, log),
+		newRestoreLabelsCommand(cfg, components, log),
+		newAuditCommand(cfg, components, log),
+		newWaitForDriverCommand(cfg, components, log),
 	}
 
-	if err := app.Run(os.Args); err != nil {
-		log.Fatal(err)
+	return app
+}
+
+// requireNodeName fails a command managing the current node when no node name is configured
+func requireNodeName(cfg *config) cli.BeforeFunc {
+	return func(*cli.Context) error {
+		if cfg.nodeName == "" {
+			return fmt.Errorf("the node-name flag or the NODE_NAME environment variable must be set")
+		}
+		return nil
 	}
 }
 
@@ -264,20 +601,91 @@ func newDriverManager(ctx context.Context, cfg *config, components *componentSta
 		ctx:        ctx,
 		config:     cfg,
 		components: components,
+		host:       linuxHost{},
 		log:        log,
 	}
 
-	kubeClient, err := kube.NewClient(ctx, cfg.kubeconfig, log)
+	if err := validateQuiesceConfig(cfg); err != nil {
+		return nil, err
+	}
+	if err := validateDrainBackend(cfg, log); err != nil {
+		return nil, err
+	}
+	if err := validateUpgradeSlotConfig(cfg); err != nil {
+		return nil, err
+	}
+	operandTimeouts, err := parseOperandTimeouts(cfg.operandTerminationTimeouts.Value())
+	if err != nil {
+		return nil, err
+	}
+	driverManager.operandTimeouts = operandTimeouts
+	autoscalerProtection, err := parseAutoscalerProtectionAnnotations(cfg.autoscalerProtectionAnnotations.Value())
+	if err != nil {
+		return nil, err
+	}
+	driverManager.autoscalerProtection = autoscalerProtection
+
+	kubeClient, err := kube.NewClient(ctx, cfg.kubeconfig, log, driverManager.kubeClientOptions()...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create kube client: %w", err)
 	}
 	driverManager.kubeClient = kubeClient
 
+	if cfg.hooksConfigFile != "" {
+		hooksConfig, err := hooks.LoadConfig(cfg.hooksConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		driverManager.hookRunner = hooks.NewRunner(log, hooksConfig)
+	}
+
 	return driverManager, nil
 }
 
+// kubeClientOptions returns the options of the Kubernetes client derived from the configuration
+func (dm *DriverManager) kubeClientOptions() []kube.Option {
+	opts := []kube.Option{
+		kube.WithGPUResourceNamePatterns(dm.config.gpuResourceNamePatterns.Value()),
+		kube.WithForceFieldOwnership(dm.config.forceLabelOwnership),
+	}
+
+	if dm.config.gpuPodDetectVisibleDevicesEnv {
+		opts = append(opts, kube.WithGPUPodClassifiers(kube.NewVisibleDevicesEnvClassifier()))
+	}
+	if dm.config.gpuPodDetectDeviceMounts {
+		opts = append(opts, kube.WithGPUPodClassifiers(kube.NewDeviceMountClassifier()))
+	}
+	if dm.config.gpuPodCorrelateHostGPUProcesses {
+		opts = append(opts, kube.WithGPUPodClassifiers(kube.NewPodUIDClassifier(dm.scanGPUProcessPodUIDs)))
+	}
+
+	return opts
+}
+
+// scanGPUProcessPodUIDs returns the UIDs of the pods owning host processes which hold NVIDIA
+// device nodes open
+func (dm *DriverManager) scanGPUProcessPodUIDs() ([]string, error) {
+	processes, err := linuxutils.ListGPUProcesses(dm.config.procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan host processes using GPUs: %w", err)
+	}
+
+	var uids []string
+	for _, p := range processes {
+		if p.PodUID == "" {
+			dm.log.Infof("Process %d using GPUs does not belong to a pod", p.PID)
+			continue
+		}
+		uids = append(uids, p.PodUID)
+	}
+	return uids, nil
+}
+
 func (dm *DriverManager) uninstallDriver() error {
 	dm.log.Info("Starting driver uninstallation process")
+	defer dm.releaseUpgradeSlot()
+	defer dm.endUpgradeDeadline()
+	defer dm.rollbackOnShutdown()
 
 	// Check if driver is pre-installed on host
 	if dm.isHostDriver() {
@@ -286,10 +694,18 @@ func (dm *DriverManager) uninstallDriver() error {
 			return fmt.Errorf("failed to disable containerized driver: %w", err)
 		}
 		// Wait for pod termination
-		time.Sleep(60 * time.Second)
+		if err := dm.sleep(60 * time.Second); err != nil {
+			return err
+		}
 		return fmt.Errorf("driver is pre-installed on host")
 	}
 
+	// Tell a reboot from a restart of the driver container before reading the component
+	// states, since an upgrade interrupted by a reboot is rolled back
+	if err := dm.reconcileBoot(); err != nil {
+		return fmt.Errorf("failed to reconcile the upgrade state with the boot of the node: %w", err)
+	}
+
 	// Fetch current component states
 	if err := dm.fetchCurrentLabels(); err != nil {
 		return fmt.Errorf("failed to fetch current labels: %w", err)
@@ -344,17 +760,43 @@ func (dm *DriverManager) uninstallDriver() error {
 		}
 
 		if dm.isGPUPodEvictionEnabled() || dm.isAutoDrainEnabled() {
-			if err := dm.kubeClient.UncordonNode(dm.config.nodeName); err != nil {
+			if err := dm.unquiesceNode(dm.config.nodeName); err != nil {
 				dm.log.Warnf("Failed to uncordon node: %v", err)
 			}
 		}
 
-		if err := dm.rescheduleGPUOperatorComponents(); err != nil {
+		if err := dm.runHooks(hooks.PhasePreReschedule); err != nil {
+			dm.cleanupOnFailure()
+			return err
+		}
+		dm.recordDriverState()
+		if err := dm.rescheduleOrDefer(); err != nil {
 			return fmt.Errorf("failed to reschedule GPU operator components: %w", err)
 		}
 		return nil
 	}
 
+	// Decide up front whether the driver has to be uninstalled, so that a disruptive
+	// upgrade waits for the maintenance window and a cluster-wide upgrade slot before
+	// any GPU client is evicted.
+	skipUninstall := dm.shouldSkipUninstall()
+	if !skipUninstall {
+		if err := dm.waitForMaintenanceWindow(); err != nil {
+			return err
+		}
+		if err := dm.acquireUpgradeSlot(); err != nil {
+			return err
+		}
+	}
+
+	// Bound the disruptive part of the upgrade, from the eviction of the GPU operator
+	// components to their rescheduling, by the upgrade deadline
+	dm.startUpgradeDeadline()
+
+	if err := dm.runHooks(hooks.PhasePreOperandEviction); err != nil {
+		return err
+	}
+
 	// Always evict all GPU operator components across a driver restart. The DRA
 	// kubelet-plugin is the exception: it services NodeUnprepareResources for the
 	// claim-holders evicted here (e.g. dra-validator), so it must outlive them and
@@ -365,7 +807,7 @@ func (dm *DriverManager) uninstallDriver() error {
 		return fmt.Errorf("failed to evict GPU operator components: %w", err)
 	}
 
-	if dm.shouldSkipUninstall() {
+	if skipUninstall {
 		dm.log.Info("The NVIDIA driver is already loaded with the desired version and configuration, skipping the uninstallation of the driver in an attempt to not disrupt running workloads")
 
 		// The DRA kubelet-plugin bind-mounts the previous driver container's rootfs.
@@ -390,35 +832,57 @@ func (dm *DriverManager) uninstallDriver() error {
 		// Remove stale PID file from previous container
 		dm.removePIDFile()
 
-		if err := dm.rescheduleGPUOperatorComponents(); err != nil {
+		if err := dm.runHooks(hooks.PhasePreReschedule); err != nil {
+			dm.cleanupOnFailure()
+			return err
+		}
+		dm.recordDriverState()
+		if err := dm.rescheduleOrDefer(); err != nil {
 			return fmt.Errorf("failed to reschedule GPU operator components: %w", err)
 		}
 		return nil
 	}
 
+	if err := dm.checkUpgradeSlot(); err != nil {
+		dm.cleanupOnFailure()
+		return err
+	}
+
+	// The node looks empty and underutilized to autoscalers once cordoned and drained
+	if err := dm.protectFromScaleDown(); err != nil {
+		dm.cleanupOnFailure()
+		return fmt.Errorf("failed to protect node from autoscaler scale-down: %w", err)
+	}
+
 	drainOpts := kube.DrainOptions{
 		Force:              dm.config.drainUseForce,
 		DeleteEmptyDirData: dm.config.drainDeleteEmptyDirData,
 		Timeout:            dm.config.drainTimeout,
 		PodSelector:        dm.config.drainPodSelectorLabel,
+		// The auto-drain fallback must not evict the GPU pods kept by the eviction policy
+		EvictionPolicy: dm.gpuPodEvictionPolicy(),
 	}
 
 	// Delete any GPU pods running on the node. With DRA, evict GPU pods up front:
 	// the post-unload auto-drain fallback runs after the kubelet-plugin is gone,
 	// leaving drained claim-holders stuck in Terminating.
 	if dm.isGPUPodEvictionEnabled() || (dm.components.draDriverDeployed != "" && dm.isAutoDrainEnabled()) {
-		if err := dm.kubeClient.CordonNode(dm.config.nodeName); err != nil {
+		if err := dm.quiesceNode(cordonReasonGPUPodEviction); err != nil {
 			return fmt.Errorf("failed to cordon node: %w", err)
 		}
 
 		if err := dm.nvDrainNode(); err != nil {
 			dm.log.Info("Failed to drain node of GPU pods")
+			if dm.ctx.Err() != nil {
+				dm.cleanupOnFailure()
+				return fmt.Errorf("failed to drain node of GPU pods: %w", err)
+			}
 			if !dm.isAutoDrainEnabled() {
 				dm.cleanupOnFailure()
 				return fmt.Errorf("cannot proceed until all GPU pods are drained from the node")
 			}
 			dm.log.Info("Attempting node drain")
-			if err := dm.kubeClient.DrainNode(dm.config.nodeName, drainOpts); err != nil {
+			if err := dm.drainNode(drainOpts); err != nil {
 				dm.cleanupOnFailure()
 				return fmt.Errorf("failed to drain node: %w", err)
 			}
@@ -450,25 +914,36 @@ func (dm *DriverManager) uninstallDriver() error {
 
 	// Check if driver is loaded and cleanup if needed
 	if dm.isDriverLoaded() {
+		if err := dm.checkUpgradeSlot(); err != nil {
+			dm.cleanupOnFailure()
+			return err
+		}
+		if err := dm.runHooks(hooks.PhasePreModuleUnload); err != nil {
+			dm.cleanupOnFailure()
+			return err
+		}
 		if err := dm.cleanupDriver(); err != nil {
 			if dm.isAutoDrainEnabled() {
 				dm.log.Info("Unable to cleanup driver modules, attempting again with node drain...")
 
-				if err := dm.kubeClient.DrainNode(dm.config.nodeName, drainOpts); err != nil {
+				if err := dm.drainNode(drainOpts); err != nil {
 					dm.cleanupOnFailure()
 					return fmt.Errorf("failed to drain node: %w", err)
 				}
 				if err := dm.cleanupDriver(); err != nil {
-					dm.cleanupOnFailure()
-					return fmt.Errorf("failed to cleanup NVIDIA driver: %w", err)
+					return dm.handleUnloadFailure(fmt.Errorf("failed to cleanup NVIDIA driver: %w", err))
 				}
 			} else {
 				dm.log.Error("Failed to uninstall nvidia driver components")
-				dm.cleanupOnFailure()
-				return fmt.Errorf("failed to uninstall nvidia driver components: %w", err)
+				return dm.handleUnloadFailure(fmt.Errorf("failed to uninstall nvidia driver components: %w", err))
 			}
 		}
 		dm.log.Info("Successfully uninstalled nvidia driver components")
+
+		if err := dm.runHooks(hooks.PhasePostModuleUnload); err != nil {
+			dm.cleanupOnFailure()
+			return err
+		}
 	} else {
 		// The kernel modules may already be unloaded, but the previous driver
 		// container rootfs can still be mounted at /run/nvidia/driver. If GPU
@@ -500,14 +975,23 @@ func (dm *DriverManager) uninstallDriver() error {
 
 	// Cleanup and reschedule components
 	if dm.isGPUPodEvictionEnabled() || dm.isAutoDrainEnabled() {
-		if err := dm.kubeClient.UncordonNode(dm.config.nodeName); err != nil {
+		if err := dm.unquiesceNode(dm.config.nodeName); err != nil {
 			dm.log.Warnf("Failed to uncordon node: %v", err)
 		}
 	}
 
-	if err := dm.rescheduleGPUOperatorComponents(); err != nil {
+	if err := dm.runHooks(hooks.PhasePreReschedule); err != nil {
+		dm.cleanupOnFailure()
+		return err
+	}
+	dm.recordDriverState()
+	if err := dm.rescheduleOrDefer(); err != nil {
 		return fmt.Errorf("failed to reschedule GPU operator components: %w", err)
 	}
+	if err := dm.endNodeMaintenance(dm.config.nodeName); err != nil {
+		dm.log.Warnf("Failed to end the maintenance of node %s: %v", dm.config.nodeName, err)
+	}
+	dm.releaseUpgradeSlot()
 
 	// Handle nouveau driver
 	if dm.isNouveauLoaded() {
@@ -523,6 +1007,9 @@ func (dm *DriverManager) uninstallDriver() error {
 
 func (dm *DriverManager) preflightCheck() error {
 	dm.log.Info("Performing preflight checks")
+	if err := dm.checkPermissions(); err != nil {
+		return err
+	}
 	// TODO: Add checks for driver package availability for current kernel
 	// TODO: Add checks for driver dependencies
 	// TODO: Add checks for entitlements(OCP)
@@ -534,8 +1021,7 @@ func (dm *DriverManager) preflightCheck() error {
 
 func (dm *DriverManager) isHostDriver() bool {
 	// Check if driver is pre-installed on the host
-	cmd := exec.Command("chroot", "/host", "nvidia-smi", "--query-gpu=driver_version", "--format=csv,noheader")
-	out, err := cmd.Output()
+	out, err := dm.host.runCommand("chroot", dm.config.hostRoot, "nvidia-smi", "--query-gpu=driver_version", "--format=csv,noheader")
 	if err != nil {
 		return false
 	}
@@ -560,6 +1046,11 @@ func (dm *DriverManager) disableContainerizedDriver() error {
 func (dm *DriverManager) fetchCurrentLabels() error {
 	dm.log.Info("Fetching current component labels")
 
+	node, err := dm.refreshNodeSnapshot()
+	if err != nil {
+		return fmt.Errorf("failed to get node %s: %w", dm.config.nodeName, err)
+	}
+
 	operandLabels := []string{
 		nvidiaOperatorValidatorDeployLabel,
 		nvidiaContainerToolkitDeployLabel,
@@ -580,22 +1071,14 @@ func (dm *DriverManager) fetchCurrentLabels() error {
 	}
 
 	for _, label := range operandLabels {
-		dm.log.Infof("Getting current value of the %q node label", label)
-		value, err := dm.kubeClient.GetNodeLabelValue(dm.config.nodeName, label)
-		if err != nil {
-			return fmt.Errorf("failed to get label %s: %w", label, err)
-		}
+		value := node.Labels[label]
 		dm.log.Infof("Current value of %q=%s", label, value)
 		dm.setComponentState(label, value)
 	}
 
 	// Handle custom operand node label
 	if dm.config.nodeLabelForGPUPodEviction != "" {
-		dm.log.Infof("Getting current value of the %q node label used by custom operands", dm.config.nodeLabelForGPUPodEviction)
-		value, err := dm.kubeClient.GetNodeLabelValue(dm.config.nodeName, dm.config.nodeLabelForGPUPodEviction)
-		if err != nil {
-			return fmt.Errorf("failed to get custom operand label %s: %w", dm.config.nodeLabelForGPUPodEviction, err)
-		}
+		value := node.Labels[dm.config.nodeLabelForGPUPodEviction]
 		dm.log.Infof("Current value of %q=%s", dm.config.nodeLabelForGPUPodEviction, value)
 		dm.components.customOperandNodeLabelValue = value
 	}
@@ -641,13 +1124,12 @@ func (dm *DriverManager) setComponentState(label, value string) {
 }
 
 func (dm *DriverManager) fetchAutoUpgradeAnnotation() error {
-	annotationValue, err := dm.kubeClient.GetNodeAnnotationValue(dm.config.nodeName,
-		"nvidia.com/gpu-driver-upgrade-enabled")
+	node, err := dm.nodeSnapshot()
 	if err != nil {
 		return fmt.Errorf("failed to get node %s annotation: %w", dm.config.nodeName, err)
 	}
 
-	dm.components.autoUpgradePolicyEnabled = annotationValue
+	dm.components.autoUpgradePolicyEnabled = node.Annotations["nvidia.com/gpu-driver-upgrade-enabled"]
 
 	dm.log.Infof("Current value of AUTO_UPGRADE_POLICY_ENABLED=%s", dm.components.autoUpgradePolicyEnabled)
 	return nil
@@ -657,39 +1139,32 @@ func (dm *DriverManager) evictAllGPUOperatorComponents() error {
 	dm.log.Info("Shutting down all GPU clients on the current node by disabling their component-specific nodeSelector labels")
 
 	// Prepare labels to update
-	operandLabels := map[string]string{
-		nvidiaOperatorValidatorDeployLabel:   dm.maybeSetPaused(dm.components.validatorDeployed),
-		nvidiaContainerToolkitDeployLabel:    dm.maybeSetPaused(dm.components.toolkitDeployed),
-		nvidiaDevicePluginDeployLabel:        dm.maybeSetPaused(dm.components.pluginDeployed),
-		nvidiaGFDDeployLabel:                 dm.maybeSetPaused(dm.components.gfdDeployed),
-		nvidiaDCGMExporterDeployLabel:        dm.maybeSetPaused(dm.components.dcgmExporterDeployed),
-		nvidiaDCGMDeployLabel:                dm.maybeSetPaused(dm.components.dcgmDeployed),
-		nvidiaNVSMDeployLabel:                dm.maybeSetPaused(dm.components.nvsmDeployed),
-		nvidiaSandboxValidatorDeployLabel:    dm.maybeSetPaused(dm.components.sandboxValidatorDeployed),
-		nvidiaSandboxDevicePluginDeployLabel: dm.maybeSetPaused(dm.components.sandboxPluginDeployed),
-		nvidiaVGPUDeviceManagerDeployLabel:   dm.maybeSetPaused(dm.components.vgpuDeviceManagerDeployed),
-		nvidiaDRAValidatorDeployLabel:        dm.maybeSetPaused(dm.components.draValidatorDeployed),
-		nvidiaDRADCGMDeployLabel:             dm.maybeSetPaused(dm.components.draDCGMDeployed),
-		nvidiaDRADCGMExporterDeployLabel:     dm.maybeSetPaused(dm.components.draDCGMExporterDeployed),
-	}
-
-	if dm.components.migManagerDeployed != "" {
-		operandLabels[nvidiaMIGManagerDeployLabel] = dm.maybeSetPaused(dm.components.migManagerDeployed)
+	operandLabels := []string{
+		nvidiaOperatorValidatorDeployLabel,
+		nvidiaContainerToolkitDeployLabel,
+		nvidiaDevicePluginDeployLabel,
+		nvidiaGFDDeployLabel,
+		nvidiaDCGMExporterDeployLabel,
+		nvidiaDCGMDeployLabel,
+		nvidiaNVSMDeployLabel,
+		nvidiaSandboxValidatorDeployLabel,
+		nvidiaSandboxDevicePluginDeployLabel,
+		nvidiaVGPUDeviceManagerDeployLabel,
+		nvidiaDRAValidatorDeployLabel,
+		nvidiaDRADCGMDeployLabel,
+		nvidiaDRADCGMExporterDeployLabel,
+		nvidiaMIGManagerDeployLabel,
+		nvidiaGPUClientDeployLabel,
 	}
 
 	// Handle custom operand node selector label
 	if dm.components.customOperandNodeLabelValue != "" {
 		dm.log.Infof("Shutting down GPU clients using node selector label %q=%s", dm.config.nodeLabelForGPUPodEviction, dm.components.customOperandNodeLabelValue)
-		operandLabels[dm.config.nodeLabelForGPUPodEviction] = dm.maybeSetPaused(dm.components.customOperandNodeLabelValue)
-	}
-
-	if dm.components.gpuClientsDeployed != "" {
-		operandLabels[nvidiaGPUClientDeployLabel] = dm.maybeSetPaused(dm.components.gpuClientsDeployed)
+		operandLabels = append(operandLabels, dm.config.nodeLabelForGPUPodEviction)
 	}
 
 	// Update the node
-	err := dm.kubeClient.UpdateNodeLabels(dm.config.nodeName, operandLabels)
-	if err != nil {
+	if err := dm.pauseOperands(operandLabels); err != nil {
 		return err
 	}
 
@@ -707,135 +1182,30 @@ func (dm *DriverManager) evictKubeletPlugin() error {
 	}
 
 	dm.log.Info("Draining the DRA kubelet-plugin (last, after its claim-holding clients)")
-	operandLabels := map[string]string{
-		nvidiaDRADriverDeployLabel: dm.maybeSetPaused(dm.components.draDriverDeployed),
-	}
-	if err := dm.kubeClient.UpdateNodeLabels(dm.config.nodeName, operandLabels); err != nil {
+	if err := dm.pauseOperands([]string{nvidiaDRADriverDeployLabel}); err != nil {
 		return err
 	}
 
-	dm.log.Info("Waiting for dra-driver to shutdown")
 	selectorMap := map[string]string{
-		"app": "nvidia-dra-driver-kubelet-plugin",
-	}
-	if err := dm.kubeClient.WaitForPodTermination(selectorMap, dm.config.operatorNamespace, dm.config.nodeName, defaultGracePeriod); err != nil {
-		dm.log.Errorf("Failed to wait for dra-driver to shutdown: %v", err)
-		return err
-	}
-	return nil
-}
-
-func (dm *DriverManager) maybeSetPaused(currentValue string) string {
-	if currentValue == "" {
-		return ""
-	} else if currentValue == "false" {
-		return "false"
-	} else if currentValue == "true" {
-		return pausedStr
-	} else if strings.Contains(currentValue, pausedStr) {
-		return currentValue
-	} else {
-		return currentValue + "_" + pausedStr
+		"app": draDriverOperandApp,
 	}
+	return dm.waitForOperandTermination(draDriverOperandName, func(timeout time.Duration) error {
+		return dm.kubeClient.WaitForPodTermination(selectorMap, dm.config.operatorNamespace, dm.config.nodeName, timeout)
+	})
 }
 
-func (dm *DriverManager) waitForPodsToTerminate() error {
-	podSelectors := []struct {
-		app     string
-		timeout time.Duration
-	}{
-		// The ClusterPolicy and GPUCluster validators intentionally share this pod
-		// label so the upgrade controller and driver-manager use the same readiness
-		// and shutdown gate.
-		{"nvidia-operator-validator", defaultGracePeriod},
-		{"nvidia-container-toolkit-daemonset", defaultGracePeriod},
-		{"nvidia-device-plugin-daemonset", defaultGracePeriod},
-		{"gpu-feature-discovery", defaultGracePeriod},
-		{"nvidia-dcgm-exporter", defaultGracePeriod},
-		{"nvidia-dcgm", defaultGracePeriod},
-		{"nvidia-dcgm-exporter-dra", defaultGracePeriod},
-		{"nvidia-dcgm-dra", defaultGracePeriod},
-	}
-
-	namespace := dm.config.operatorNamespace
-	nodeName := dm.config.nodeName
-
-	for _, selector := range podSelectors {
-		dm.log.Infof("Waiting for %s to shutdown", selector.app)
-		selectorMap := map[string]string{
-			"app": selector.app,
-		}
-		if err := dm.kubeClient.WaitForPodTermination(selectorMap, namespace, nodeName, selector.timeout); err != nil {
-			dm.log.Errorf("Failed to wait for %s to shutdown: %v", selector.app, err)
-			return err
-		}
-	}
-
-	// Handle optional components
-	if dm.components.migManagerDeployed != "" {
-		dm.log.Info("Waiting for mig-manager to shutdown")
-		selectorMap := map[string]string{
-			"app": "nvidia-mig-manager",
-		}
-		if err := dm.kubeClient.WaitForPodTermination(selectorMap, namespace, nodeName, defaultGracePeriod); err != nil {
-			dm.log.Errorf("Failed to wait for mig-manager to shutdown: %v", err)
-			return err
-		}
-	}
-
-	if dm.components.sandboxValidatorDeployed != "" {
-		dm.log.Info("Waiting for sandbox-validator to shutdown")
-		selectorMap := map[string]string{
-			"app": "nvidia-sandbox-validator",
-		}
-		if err := dm.kubeClient.WaitForPodTermination(selectorMap, namespace, nodeName, defaultGracePeriod); err != nil {
-			dm.log.Errorf("Failed to wait for sandbox-validator to shutdown: %v", err)
-			return err
-		}
-	}
-
-	if dm.components.sandboxPluginDeployed != "" {
-		dm.log.Info("Waiting for sandbox-device-plugin to shutdown")
-		selectorMap := map[string]string{
-			"app": "nvidia-sandbox-device-plugin-daemonset",
-		}
-		if err := dm.kubeClient.WaitForPodTermination(selectorMap, namespace, nodeName, defaultGracePeriod); err != nil {
-			dm.log.Errorf("Failed to wait for sandbox-device-plugin to shutdown: %v", err)
-			return err
-		}
-	}
-
-	if dm.components.vgpuDeviceManagerDeployed != "" {
-		dm.log.Info("Waiting for vgpu-device-manager to shutdown")
-		selectorMap := map[string]string{
-			"app": "nvidia-vgpu-device-manager",
-		}
-		if err := dm.kubeClient.WaitForPodTermination(selectorMap, namespace, nodeName, defaultGracePeriod); err != nil {
-			dm.log.Errorf("Failed to wait for vgpu-device-manager to shutdown: %v", err)
-			return err
-		}
-	}
-
-	// Wait for any pods whose parent controller uses nvidia.com/gpu.deploy.client as a nodeSelector key.
-	if dm.components.gpuClientsDeployed != "" {
-		dm.log.Infof("Waiting for any daemon set pods with nodeSelector key %s to terminate", nvidiaGPUClientDeployLabel)
-		if err := dm.kubeClient.WaitForPodsWithNodeSelector(nodeName, nvidiaGPUClientDeployLabel, defaultGracePeriod); err != nil {
-			dm.log.Errorf("Failed to wait for GPU client pods to terminate: %v", err)
-			return err
-		}
-	}
-
-	return nil
+func (dm *DriverManager) isDriverLoaded() bool {
+	return dm.isModuleLoaded("nvidia")
 }
 
-func (dm *DriverManager) isDriverLoaded() bool {
-	_, err := os.Stat("/sys/module/nvidia/refcnt")
-	return err == nil
+// isModuleLoaded reports whether a kernel module is loaded
+func (dm *DriverManager) isModuleLoaded(module string) bool {
+	return dm.host.pathExists(dm.sysfsPath("module", module, "refcnt"))
 }
 
 // readStoredDigest reads the driver configuration digest from the state file
-func readStoredDigest() (string, error) {
-	data, err := os.ReadFile(driverConfigStateFile)
+func (dm *DriverManager) readStoredDigest() (string, error) {
+	data, err := dm.host.readFile(dm.driverConfigStateFile())
 	if err != nil {
 		return "", err
 	}
@@ -856,7 +1226,7 @@ func (dm *DriverManager) shouldUpdateDriverConfig() bool {
 		return true
 	}
 
-	storedDigest, err := readStoredDigest()
+	storedDigest, err := dm.readStoredDigest()
 	if err != nil {
 		if os.IsNotExist(err) {
 			dm.log.Info("No previous driver configuration found")
@@ -883,18 +1253,17 @@ func (dm *DriverManager) shouldSkipUninstall() bool {
 }
 
 func (dm *DriverManager) isNouveauLoaded() bool {
-	_, err := os.Stat("/sys/module/nouveau/refcnt")
-	return err == nil
+	return dm.isModuleLoaded("nouveau")
 }
 
 func (dm *DriverManager) unloadNouveau() error {
 	dm.log.Info("Unloading nouveau driver")
-	return unix.DeleteModule("nouveau", 0)
+	return dm.host.deleteModule("nouveau")
 }
 
 func (dm *DriverManager) removePIDFile() {
-	if err := os.Remove(driverPIDFile); err != nil && !os.IsNotExist(err) {
-		dm.log.Warnf("Failed to remove PID file %s: %v", driverPIDFile, err)
+	if err := dm.host.removeFile(dm.driverPIDFile()); err != nil && !os.IsNotExist(err) {
+		dm.log.Warnf("Failed to remove PID file %s: %v", dm.driverPIDFile(), err)
 	}
 }
 
@@ -931,8 +1300,8 @@ func (dm *DriverManager) unloadDriver() error {
 
 	var moduleErrs error
 	for _, module := range modules {
-		if _, err := os.Stat(fmt.Sprintf("/sys/module/%s/refcnt", module)); err == nil {
-			if err := unix.DeleteModule(module, 0); err != nil {
+		if dm.isModuleLoaded(module) {
+			if err := dm.host.deleteModule(module); err != nil {
 				dm.log.Warnf("Failed to unload kernel module %s: %v", module, err)
 				moduleErrs = errors.Join(moduleErrs, err)
 			}
@@ -941,7 +1310,7 @@ func (dm *DriverManager) unloadDriver() error {
 
 	if moduleErrs != nil {
 		dm.log.Info("Could not unload NVIDIA driver kernel modules, driver is in use")
-		km := linuxutils.NewKernelModules(dm.log)
+		km := linuxutils.NewKernelModules(dm.log, linuxutils.WithProcRoot(dm.config.procRoot))
 		err := km.List("nvidia")
 		if err != nil {
 			dm.log.Warnf("Failed to list kernel modules: %v", err)
@@ -956,13 +1325,14 @@ func (dm *DriverManager) unmountRootfs() error {
 	dm.log.Info("Unmounting NVIDIA driver rootfs")
 
 	// Check if the mount point exists
-	if _, err := os.Stat(driverRoot); os.IsNotExist(err) {
+	driverRoot := dm.driverRoot()
+	if !dm.host.pathExists(driverRoot) {
 		dm.log.Info("Driver root directory does not exist, nothing to unmount")
 		return nil
 	}
 
 	// Recursively unmount all mounts under the driver root
-	if err := mount.RecursiveUnmount(driverRoot); err != nil {
+	if err := dm.host.recursiveUnmount(driverRoot); err != nil {
 		return fmt.Errorf("failed to recursively unmount %s: %w", driverRoot, err)
 	}
 
@@ -975,8 +1345,8 @@ func (dm *DriverManager) unmountRootfs() error {
 // so the below unbind operation will be a no-op.
 func (dm *DriverManager) unbindVfioPCI() error {
 	dm.log.Info("Unbinding vfio-pci driver from all devices")
-	cmd := exec.Command("vfio-manage", "unbind", "--all")
-	return cmd.Run()
+	_, err := dm.host.runCommand("vfio-manage", "unbind", "--all")
+	return err
 }
 
 func (dm *DriverManager) isGPUDirectRDMAEnabled() bool {
@@ -987,14 +1357,15 @@ func (dm *DriverManager) isGPUDirectRDMAEnabled() bool {
 }
 
 func (dm *DriverManager) mellanoxDevicesPresent() bool {
-	entries, err := os.ReadDir("/sys/bus/pci/devices")
+	pciDevicesRoot := dm.sysfsPath("bus", "pci", "devices")
+	entries, err := dm.host.readDir(pciDevicesRoot)
 	if err != nil {
 		return false
 	}
 
 	for _, entry := range entries {
-		vendorFile := filepath.Join("/sys/bus/pci/devices", entry.Name(), "vendor")
-		if data, err := os.ReadFile(vendorFile); err == nil {
+		vendorFile := filepath.Join(pciDevicesRoot, entry.Name(), "vendor")
+		if data, err := dm.host.readFile(vendorFile); err == nil {
 			if strings.TrimSpace(string(data)) == "0x15b3" {
 				dm.log.Infof("Mellanox device found at %s", entry.Name())
 				return true
@@ -1012,23 +1383,24 @@ func (dm *DriverManager) waitForMofedDriver() error {
 	var isMofedLoaded func() bool
 	if dm.config.useHostMofed {
 		isMofedLoaded = func() bool {
-			loadedModules, err := os.ReadFile("/proc/modules")
+			loadedModules, err := dm.host.readFile(dm.procPath("modules"))
 			if err != nil {
-				dm.log.Warnf("Failed to read /proc/modules: %v", err)
+				dm.log.Warnf("Failed to read %s: %v", dm.procPath("modules"), err)
 				return false
 			}
 			return strings.Contains(string(loadedModules), "mlx5_core")
 		}
 	} else {
 		isMofedLoaded = func() bool {
-			_, err := os.Stat("/run/mellanox/drivers/.driver-ready")
-			return err == nil
+			return dm.host.pathExists(dm.mofedReadyFile())
 		}
 	}
 
 	for !isMofedLoaded() {
 		dm.log.Info("Waiting for MOFED to be installed...")
-		time.Sleep(5 * time.Second)
+		if err := dm.sleep(5 * time.Second); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -1037,52 +1409,21 @@ func (dm *DriverManager) waitForMofedDriver() error {
 func (dm *DriverManager) rescheduleGPUOperatorComponents() error {
 	dm.log.Info("Rescheduling all GPU clients on the current node by enabling their component-specific nodeSelector labels")
 
-	// Prepare labels for update
-	operandLabels := map[string]string{
-		nvidiaOperatorValidatorDeployLabel:   dm.maybeSetTrue(dm.components.validatorDeployed),
-		nvidiaContainerToolkitDeployLabel:    dm.maybeSetTrue(dm.components.toolkitDeployed),
-		nvidiaDevicePluginDeployLabel:        dm.maybeSetTrue(dm.components.pluginDeployed),
-		nvidiaGFDDeployLabel:                 dm.maybeSetTrue(dm.components.gfdDeployed),
-		nvidiaDCGMExporterDeployLabel:        dm.maybeSetTrue(dm.components.dcgmExporterDeployed),
-		nvidiaDCGMDeployLabel:                dm.maybeSetTrue(dm.components.dcgmDeployed),
-		nvidiaNVSMDeployLabel:                dm.maybeSetTrue(dm.components.nvsmDeployed),
-		nvidiaSandboxValidatorDeployLabel:    dm.maybeSetTrue(dm.components.sandboxValidatorDeployed),
-		nvidiaSandboxDevicePluginDeployLabel: dm.maybeSetTrue(dm.components.sandboxPluginDeployed),
-		nvidiaVGPUDeviceManagerDeployLabel:   dm.maybeSetTrue(dm.components.vgpuDeviceManagerDeployed),
-		nvidiaDRADriverDeployLabel:           dm.maybeSetTrue(dm.components.draDriverDeployed),
-		nvidiaDRAValidatorDeployLabel:        dm.maybeSetTrue(dm.components.draValidatorDeployed),
-		nvidiaDRADCGMDeployLabel:             dm.maybeSetTrue(dm.components.draDCGMDeployed),
-		nvidiaDRADCGMExporterDeployLabel:     dm.maybeSetTrue(dm.components.draDCGMExporterDeployed),
-	}
-
-	if dm.components.migManagerDeployed != "" {
-		operandLabels[nvidiaMIGManagerDeployLabel] = dm.maybeSetTrue(dm.components.migManagerDeployed)
+	node, err := dm.nodeSnapshot()
+	if err != nil {
+		return err
 	}
-
-	// Handle custom operand node selector label
-	if dm.components.customOperandNodeLabelValue != "" {
-		operandLabels[dm.config.nodeLabelForGPUPodEviction] = dm.maybeSetTrue(dm.components.customOperandNodeLabelValue)
+	if _, err := dm.resumeOperands(node); err != nil {
+		return err
 	}
-
-	if dm.components.gpuClientsDeployed != "" {
-		operandLabels[nvidiaGPUClientDeployLabel] = dm.maybeSetTrue(dm.components.gpuClientsDeployed)
+	dm.operandsPaused = false
+	if err := dm.unprotectFromScaleDown(dm.config.nodeName); err != nil {
+		dm.log.Warnf("Failed to lift the autoscaler scale-down protection: %v", err)
 	}
-
-	// Update the node
-	return dm.kubeClient.UpdateNodeLabels(dm.config.nodeName, operandLabels)
-}
-
-func (dm *DriverManager) maybeSetTrue(currentValue string) string {
-	switch currentValue {
-	case "false":
-		return "false"
-	case pausedStr:
-		return "true"
-	default:
-		re := regexp.MustCompile(pausedStr + "_?")
-		result := re.ReplaceAllString(currentValue, "")
-		return strings.Trim(result, "_")
+	if dm.rebooted {
+		dm.log.Infof("Completed the reboot-driven driver upgrade of node %s", dm.config.nodeName)
 	}
+	return nil
 }
 
 // Policy and feature check methods
@@ -1103,18 +1444,62 @@ func (dm *DriverManager) isGPUPodEvictionEnabled() bool {
 	return dm.config.enableGPUPodEviction
 }
 
+// gpuPodEvictionPolicy returns the configured policy deciding which GPU pods may be evicted
+func (dm *DriverManager) gpuPodEvictionPolicy() kube.GPUPodEvictionPolicy {
+	return kube.GPUPodEvictionPolicy{
+		ExcludedNamespaces: dm.config.gpuPodEvictionExcludedNamespaces.Value(),
+		RequireOptIn:       dm.config.gpuPodEvictionRequireOptIn,
+		PriorityWait:       dm.config.gpuPodEvictionPriorityWait,
+	}
+}
+
 func (dm *DriverManager) nvDrainNode() error {
+	policy := dm.gpuPodEvictionPolicy()
+
+	if err := dm.notifyGPUPodsOfEviction(policy); err != nil {
+		return fmt.Errorf("failed to notify GPU pods of their eviction: %w", err)
+	}
+
 	dm.log.Infof("Draining node %s of any GPU pods...", dm.config.nodeName)
 	drainOpts := kube.DrainOptions{
 		Force:              dm.config.drainUseForce,
 		DeleteEmptyDirData: dm.config.drainDeleteEmptyDirData,
 		Timeout:            dm.config.drainTimeout,
 		PodSelector:        dm.config.drainPodSelectorLabel,
+		GracePeriodSeconds: dm.config.gpuPodEvictionGracePeriodSeconds,
+		EvictionPolicy:     policy,
 	}
 
 	return dm.kubeClient.DeleteOrEvictPods(dm.config.nodeName, drainOpts)
 }
 
+// notifyGPUPodsOfEviction gives GPU workloads a chance to checkpoint before they are evicted by
+// marking them with their eviction deadline and waiting for the configured notice period.
+func (dm *DriverManager) notifyGPUPodsOfEviction(policy kube.GPUPodEvictionPolicy) error {
+	if dm.config.gpuPodEvictionNoticePeriod <= 0 {
+		return nil
+	}
+
+	deadline := time.Now().Add(dm.config.gpuPodEvictionNoticePeriod)
+	notified, err := dm.kubeClient.NotifyGPUPodsOfEviction(dm.config.nodeName, deadline, dm.config.gpuPodEvictionNoticeCondition, policy)
+	if err != nil {
+		return err
+	}
+	if len(notified) == 0 {
+		return nil
+	}
+
+	dm.log.Infof("Waiting %s for %d GPU pod(s) to prepare for eviction", dm.config.gpuPodEvictionNoticePeriod, len(notified))
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	select {
+	case <-dm.ctx.Done():
+		return dm.ctx.Err()
+	case <-timer.C:
+	}
+	return nil
+}
+
 func (dm *DriverManager) isDriverAutoUpgradePolicyEnabled() bool {
 	if dm.components.autoUpgradePolicyEnabled == "true" {
 		return true
@@ -1125,12 +1510,13 @@ func (dm *DriverManager) isDriverAutoUpgradePolicyEnabled() bool {
 
 func (dm *DriverManager) cleanupOnFailure() {
 	dm.log.Info("Performing cleanup on failure")
+	defer dm.useRollbackContext()()
 	policyEnabled := dm.isDriverAutoUpgradePolicyEnabled()
 	managerCanEvict := !policyEnabled && (dm.config.enableGPUPodEviction || dm.config.enableAutoDrain)
 
 	switch {
 	case managerCanEvict:
-		if err := dm.kubeClient.UncordonNode(dm.config.nodeName); err != nil {
+		if err := dm.unquiesceNode(dm.config.nodeName); err != nil {
 			dm.log.Warnf("Failed to uncordon node during cleanup: %v", err)
 		}
 	case policyEnabled:
@@ -1141,5 +1527,59 @@ func (dm *DriverManager) cleanupOnFailure() {
 
 	if err := dm.rescheduleGPUOperatorComponents(); err != nil {
 		dm.log.Warnf("Failed to reschedule GPU operator components during cleanup: %v", err)
+		if err := dm.unprotectFromScaleDown(dm.config.nodeName); err != nil {
+			dm.log.Warnf("Failed to lift the autoscaler scale-down protection during cleanup: %v", err)
+		}
+	}
+	if managerCanEvict {
+		if err := dm.endNodeMaintenance(dm.config.nodeName); err != nil {
+			dm.log.Warnf("Failed to end the maintenance of node %s during cleanup: %v", dm.config.nodeName, err)
+		}
+	}
+	dm.releaseUpgradeSlot()
+}
+
+// acquireUpgradeSlot waits for one of the cluster-wide driver upgrade slots when the number
+// of concurrent upgrades is limited
+func (dm *DriverManager) acquireUpgradeSlot() error {
+	if dm.config.maxConcurrentUpgrades <= 0 || dm.upgradeSlot != nil {
+		return nil
+	}
+
+	slot, err := dm.kubeClient.AcquireUpgradeSlot(dm.config.operatorNamespace, dm.config.nodeName,
+		dm.config.maxConcurrentUpgrades, dm.config.upgradeSlotStaleTimeout)
+	if err != nil {
+		return err
+	}
+	dm.upgradeSlot = slot
+	return nil
+}
+
+// validateUpgradeSlotConfig checks the stale timeout of the upgrade slots when the number of
+// concurrent upgrades is limited
+func validateUpgradeSlotConfig(cfg *config) error {
+	if cfg.maxConcurrentUpgrades > 0 && cfg.upgradeSlotStaleTimeout < minUpgradeSlotStaleTimeout {
+		return fmt.Errorf("upgrade slot stale timeout %s is shorter than the minimum of %s", cfg.upgradeSlotStaleTimeout, minUpgradeSlotStaleTimeout)
+	}
+	return nil
+}
+
+// checkUpgradeSlot fails if the node may no longer hold its upgrade slot, so that the
+// disruptive steps which follow do not exceed the limit of concurrent upgrades
+func (dm *DriverManager) checkUpgradeSlot() error {
+	if dm.upgradeSlot == nil {
+		return nil
+	}
+	return dm.upgradeSlot.Check()
+}
+
+// releaseUpgradeSlot frees the upgrade slot held by the node, if any
+func (dm *DriverManager) releaseUpgradeSlot() {
+	if dm.upgradeSlot == nil {
+		return
+	}
+	defer dm.useRollbackContext()()
+	if err := dm.upgradeSlot.ReleaseWithContext(dm.ctx); err != nil {
+		dm.log.Warnf("Failed to release driver upgrade slot: %v", err)
 	}
 }
@@ -0,0 +1,868 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	resourcev1 "k8s.io/api/resource/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/NVIDIA/k8s-driver-manager/internal/hooks"
+	kube "github.com/NVIDIA/k8s-driver-manager/internal/kubernetes"
+)
+
+const (
+	testNodeName     = "gpu-node"
+	testConfigDigest = "digest-1"
+	// testProtectionAnnotation protects the node from autoscaler scale-down
+	testProtectionAnnotation = "karpenter.sh/do-not-disrupt"
+)
+
+// fakeHost is an in-memory host whose files are keyed by absolute path
+type fakeHost struct {
+	files map[string]string
+	// busyModules counts how many more times unloading each module fails with EBUSY
+	busyModules map[string]int
+
+	unloadedModules []string
+	unmounted       []string
+}
+
+var _ host = (*fakeHost)(nil)
+
+func newFakeHost(loadedModules ...string) *fakeHost {
+	h := &fakeHost{
+		files:       make(map[string]string),
+		busyModules: make(map[string]int),
+	}
+	for _, module := range loadedModules {
+		h.files[fmt.Sprintf("/sys/module/%s/refcnt", module)] = "0"
+	}
+	return h
+}
+
+func (h *fakeHost) pathExists(path string) bool {
+	if _, ok := h.files[path]; ok {
+		return true
+	}
+	for file := range h.files {
+		if strings.HasPrefix(file, path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *fakeHost) readFile(path string) ([]byte, error) {
+	data, ok := h.files[path]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	return []byte(data), nil
+}
+
+func (h *fakeHost) readDir(path string) ([]os.DirEntry, error) {
+	return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+}
+
+func (h *fakeHost) writeFile(path string, data []byte) error {
+	h.files[path] = string(data)
+	return nil
+}
+
+func (h *fakeHost) removeFile(path string) error {
+	if _, ok := h.files[path]; !ok {
+		return &fs.PathError{Op: "remove", Path: path, Err: fs.ErrNotExist}
+	}
+	delete(h.files, path)
+	return nil
+}
+
+func (h *fakeHost) deleteModule(name string) error {
+	if h.busyModules[name] > 0 {
+		h.busyModules[name]--
+		return unix.EBUSY
+	}
+	delete(h.files, fmt.Sprintf("/sys/module/%s/refcnt", name))
+	h.unloadedModules = append(h.unloadedModules, name)
+	return nil
+}
+
+func (h *fakeHost) recursiveUnmount(path string) error {
+	h.unmounted = append(h.unmounted, path)
+	return nil
+}
+
+func (h *fakeHost) runCommand(name string, args ...string) ([]byte, error) {
+	// There is no driver pre-installed on the fake host
+	if name == "chroot" {
+		return nil, fmt.Errorf("nvidia-smi: not found")
+	}
+	return nil, nil
+}
+
+func newTestNode(labels, annotations map[string]string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        testNodeName,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+	}
+}
+
+func newTestGPUPod(name string) *corev1.Pod {
+	controller := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      name,
+			UID:       types.UID("uid-" + name),
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       "ReplicaSet",
+				Name:       name + "-rs",
+				Controller: &controller,
+			}},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: testNodeName,
+			Containers: []corev1.Container{{
+				Name: "cuda",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+				},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+// newNeverEvictTestGPUPod returns a GPU pod which is never evicted by driver-manager
+func newNeverEvictTestGPUPod(name string) *corev1.Pod {
+	pod := newTestGPUPod(name)
+	pod.Annotations = map[string]string{kube.GPUPodNeverEvictAnnotation: "true"}
+	return pod
+}
+
+func newTestDRAClaimHolder(name string) []runtime.Object {
+	claimName := name + "-claim"
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec: corev1.PodSpec{
+			NodeName:       testNodeName,
+			Containers:     []corev1.Container{{Name: "cuda"}},
+			ResourceClaims: []corev1.PodResourceClaim{{Name: "gpu", ResourceClaimName: &claimName}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	claim := &resourcev1.ResourceClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: claimName},
+		Status: resourcev1.ResourceClaimStatus{
+			Allocation: &resourcev1.AllocationResult{
+				Devices: resourcev1.DeviceAllocationResult{
+					Results: []resourcev1.DeviceRequestAllocationResult{{Driver: "gpu.nvidia.com", Device: "gpu-0"}},
+				},
+			},
+		},
+	}
+	return []runtime.Object{pod, claim}
+}
+
+func defaultTestOperandLabels() map[string]string {
+	return map[string]string{
+		nvidiaDriverDeployLabel:            "true",
+		nvidiaOperatorValidatorDeployLabel: "true",
+		nvidiaContainerToolkitDeployLabel:  "true",
+		nvidiaDevicePluginDeployLabel:      "true",
+		nvidiaGFDDeployLabel:               "true",
+		nvidiaDCGMExporterDeployLabel:      "true",
+		nvidiaDCGMDeployLabel:              "true",
+	}
+}
+
+func withLabels(labels map[string]string, extra map[string]string) map[string]string {
+	for k, v := range extra {
+		labels[k] = v
+	}
+	return labels
+}
+
+func TestUninstallDriverLostUpgradeSlot(t *testing.T) {
+	t.Setenv("DRIVER_CONFIG_DIGEST", testConfigDigest)
+
+	clientset := fake.NewClientset(newTestNode(defaultTestOperandLabels(), nil), newTestGPUPod("training"))
+	// Once created, the Lease of the upgrade slot is taken over by another node
+	var created atomic.Bool
+	clientset.PrependReactor("create", "leases", func(k8stesting.Action) (bool, runtime.Object, error) {
+		created.Store(true)
+		return false, nil, nil
+	})
+	clientset.PrependReactor("get", "leases", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if !created.Load() {
+			return false, nil, nil
+		}
+		holder := "gpu-node-2"
+		return true, &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Namespace: operatorNamespace, Name: action.(k8stesting.GetAction).GetName()},
+			Spec:       coordinationv1.LeaseSpec{HolderIdentity: &holder},
+		}, nil
+	})
+
+	h := newFakeHost("nvidia")
+	dm := newTestDriverManager(t, clientset, h, func(cfg *config) {
+		cfg.maxConcurrentUpgrades = 1
+		cfg.upgradeSlotStaleTimeout = 300 * time.Millisecond
+	})
+	// Leave time for the slot to be renewed before the GPU pods are evicted
+	dm.hookRunner = newTestHookRunner(t, dm.log, hooks.PhasePreOperandEviction, "sleep", "0.3")
+
+	err := dm.uninstallDriver()
+	require.ErrorContains(t, err, "lost driver upgrade slot")
+	require.Empty(t, h.unloadedModules)
+	_, err = clientset.CoreV1().Pods("default").Get(context.Background(), "training", metav1.GetOptions{})
+	require.NoError(t, err, "GPU pod was evicted")
+	node, err := clientset.CoreV1().Nodes().Get(context.Background(), testNodeName, metav1.GetOptions{})
+	require.NoError(t, err)
+	for label, value := range defaultTestOperandLabels() {
+		require.Equal(t, value, node.Labels[label], "label %s", label)
+	}
+}
+
+func TestValidateUpgradeSlotConfig(t *testing.T) {
+	testCases := []struct {
+		description   string
+		cfg           config
+		expectedError bool
+	}{
+		{
+			description: "default stale timeout",
+			cfg:         config{maxConcurrentUpgrades: 1, upgradeSlotStaleTimeout: defaultUpgradeSlotStaleTimeout},
+		},
+		{
+			description:   "zero stale timeout",
+			cfg:           config{maxConcurrentUpgrades: 1},
+			expectedError: true,
+		},
+		{
+			description:   "sub-second stale timeout",
+			cfg:           config{maxConcurrentUpgrades: 1, upgradeSlotStaleTimeout: 500 * time.Millisecond},
+			expectedError: true,
+		},
+		{
+			description: "stale timeout without a limit of concurrent upgrades",
+			cfg:         config{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			err := validateUpgradeSlotConfig(&tc.cfg)
+			if tc.expectedError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLoadMaintenanceWindow(t *testing.T) {
+	const configMapName = "maintenance-window"
+
+	testCases := []struct {
+		description      string
+		annotation       string
+		configMap        bool
+		flags            bool
+		expectedSchedule string
+		expectedError    bool
+	}{
+		{
+			description: "no maintenance window",
+		},
+		{
+			description:      "window set through flags",
+			flags:            true,
+			expectedSchedule: "0 4 * * *",
+		},
+		{
+			description:      "ConfigMap window takes precedence over flags",
+			configMap:        true,
+			flags:            true,
+			expectedSchedule: "0 3 * * *",
+		},
+		{
+			description:      "annotation window takes precedence over ConfigMap and flags",
+			annotation:       `{"schedule":"0 2 * * 6","timezone":"Europe/Berlin","duration":"4h"}`,
+			configMap:        true,
+			flags:            true,
+			expectedSchedule: "0 2 * * 6",
+		},
+		{
+			description:   "invalid annotation",
+			annotation:    `{"schedule":`,
+			configMap:     true,
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			var annotations map[string]string
+			if tc.annotation != "" {
+				annotations = map[string]string{maintenanceWindowAnnotation: tc.annotation}
+			}
+			clientset := fake.NewClientset(newTestNode(nil, annotations), &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Namespace: operatorNamespace, Name: configMapName},
+				Data:       map[string]string{"schedule": "0 3 * * *", "duration": "2h"},
+			})
+			dm := newTestDriverManager(t, clientset, newFakeHost(), func(cfg *config) {
+				if tc.configMap {
+					cfg.maintenanceWindowConfigMap = configMapName
+				}
+				if tc.flags {
+					cfg.maintenanceWindowSchedule = "0 4 * * *"
+					cfg.maintenanceWindowDuration = "1h"
+				}
+			})
+
+			window, err := dm.loadMaintenanceWindow()
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tc.expectedSchedule == "" {
+				require.Nil(t, window)
+				return
+			}
+			require.NotNil(t, window)
+			require.Equal(t, tc.expectedSchedule, window.Schedule)
+		})
+	}
+}
+
+func TestWaitForMaintenanceWindow(t *testing.T) {
+	testCases := []struct {
+		description    string
+		schedule       string
+		condition      corev1.ConditionStatus
+		expectedWait   bool
+		expectedStatus corev1.ConditionStatus
+	}{
+		{
+			description: "no maintenance window",
+		},
+		{
+			description:    "stale wait cleared without a maintenance window",
+			condition:      corev1.ConditionTrue,
+			expectedStatus: corev1.ConditionFalse,
+		},
+		{
+			description: "maintenance window open",
+			schedule:    "* * * * *",
+		},
+		{
+			description:    "stale wait cleared within the maintenance window",
+			schedule:       "* * * * *",
+			condition:      corev1.ConditionTrue,
+			expectedStatus: corev1.ConditionFalse,
+		},
+		{
+			description:    "maintenance window closed",
+			schedule:       "0 0 29 2 *",
+			expectedWait:   true,
+			expectedStatus: corev1.ConditionTrue,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			node := newTestNode(nil, nil)
+			if tc.condition != "" {
+				node.Status.Conditions = []corev1.NodeCondition{{
+					Type:   maintenanceWindowConditionType,
+					Status: tc.condition,
+					Reason: "WaitingForMaintenanceWindow",
+				}}
+			}
+			clientset := fake.NewClientset(node)
+			dm := newTestDriverManager(t, clientset, newFakeHost(), func(cfg *config) {
+				cfg.maintenanceWindowSchedule = tc.schedule
+				cfg.maintenanceWindowDuration = "1h"
+			})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			dm.ctx, dm.kubeClient = ctx, dm.kubeClient.WithContext(ctx)
+
+			conditionStatus := func() corev1.ConditionStatus {
+				status, err := dm.kubeClient.WithContext(context.Background()).GetNodeConditionStatus(testNodeName, maintenanceWindowConditionType)
+				require.NoError(t, err)
+				return status
+			}
+			if tc.expectedWait {
+				// Terminate driver-manager once it reported the wait
+				go func() {
+					require.Eventually(t, func() bool {
+						return conditionStatus() == corev1.ConditionTrue
+					}, 5*time.Second, 10*time.Millisecond)
+					cancel()
+				}()
+				require.ErrorIs(t, dm.waitForMaintenanceWindow(), context.Canceled)
+			} else {
+				require.NoError(t, dm.waitForMaintenanceWindow())
+			}
+			require.Equal(t, tc.expectedStatus, conditionStatus())
+		})
+	}
+}
+
+func newTestDriverManager(t *testing.T, clientset *fake.Clientset, h host, modify func(*config)) *DriverManager {
+	log := logrus.New()
+	log.SetOutput(testWriter{t})
+
+	cfg := &config{
+		nodeName:                         testNodeName,
+		enableAutoDrain:                  true,
+		enableGPUPodEviction:             true,
+		operatorNamespace:                operatorNamespace,
+		gpuPodEvictionGracePeriodSeconds: defaultGPUPodEvictionGracePeriodSeconds,
+		hostRoot:                         defaultHostRoot,
+		sysfsRoot:                        defaultSysfsRoot,
+		procRoot:                         defaultProcRoot,
+		runDir:                           defaultRunDir,
+		quiesceMode:                      quiesceModeCordon,
+		quiesceTaintEffect:               defaultQuiesceTaintEffect,
+		operandTerminationTimeout:        defaultGracePeriod,
+		// The operand labels of the test nodes are owned by the field manager of the fake
+		// clientset rather than driver-manager
+		forceLabelOwnership: true,
+	}
+	if modify != nil {
+		modify(cfg)
+	}
+
+	ctx := context.Background()
+	kubeClient, err := kube.NewClientFromClientset(ctx, clientset, log,
+		kube.WithPollInterval(10*time.Millisecond),
+		kube.WithForceFieldOwnership(cfg.forceLabelOwnership))
+	require.NoError(t, err)
+
+	return &DriverManager{
+		ctx:        ctx,
+		config:     cfg,
+		components: &componentState{},
+		kubeClient: kubeClient,
+		host:       h,
+		log:        log,
+	}
+}
+
+// newFailingHookRunner returns a hook runner with a fail-closed hook failing in the phase
+func newFailingHookRunner(t *testing.T, log *logrus.Logger, phase hooks.Phase) *hooks.Runner {
+	return newTestHookRunner(t, log, phase, "false")
+}
+
+// newTestHookRunner returns a hook runner with a fail-closed hook running the command in the phase
+func newTestHookRunner(t *testing.T, log *logrus.Logger, phase hooks.Phase, command ...string) *hooks.Runner {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = fmt.Sprintf("%q", arg)
+	}
+	path := filepath.Join(t.TempDir(), "hooks.yaml")
+	content := fmt.Sprintf("hooks:\n- name: test\n  phases: [%s]\n  exec:\n    command: [%s]\n", phase, strings.Join(quoted, ", "))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	config, err := hooks.LoadConfig(path)
+	require.NoError(t, err)
+	return hooks.NewRunner(log, config)
+}
+
+// runTestApp runs the driver-manager application with the arguments against an API server
+// serving the nodes, and returns the requests made to the API server
+func runTestApp(t *testing.T, nodes []corev1.Node, args ...string) ([]string, error) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodGet || r.URL.Path != "/api/v1/nodes" {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(metav1.Status{Status: metav1.StatusFailure, Reason: metav1.StatusReasonNotFound, Code: http.StatusNotFound})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(corev1.NodeList{Items: nodes})
+	}))
+	t.Cleanup(server.Close)
+
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	content := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+users:
+- name: test
+  user: {}
+`, server.URL)
+	require.NoError(t, os.WriteFile(kubeconfig, []byte(content), 0600))
+
+	log := logrus.New()
+	log.SetOutput(testWriter{t})
+	app := newApp(&config{}, &componentState{}, log)
+	app.Writer = testWriter{t}
+	app.ErrWriter = testWriter{t}
+	err := app.RunContext(context.Background(), append([]string{"driver-manager", "--kubeconfig", kubeconfig}, args...))
+	return requests, err
+}
+
+// testWriter routes log output through the test so it is only shown for failing tests
+type testWriter struct {
+	t *testing.T
+}
+
+func (w testWriter) Write(p []byte) (int, error) {
+	w.t.Log(strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+func TestUninstallDriver(t *testing.T) {
+	testCases := []struct {
+		description     string
+		nodeLabels      map[string]string
+		nodeAnnotations map[string]string
+		// nodeCordoned cordons the node before driver-manager runs, as an admin would
+		nodeCordoned  bool
+		objects       []runtime.Object
+		loadedModules []string
+		busyModules   map[string]int
+		storedDigest  string
+		modifyConfig  func(*config)
+		// failingHook is the phase of a fail-closed hook which fails
+		failingHook hooks.Phase
+
+		// expectedUnprotected asserts that the node is never protected from autoscaler
+		// scale-down
+		expectedUnprotected     bool
+		expectedError           bool
+		expectedLabels          map[string]string
+		expectedCordoned        bool
+		expectedUnloadedModules []string
+		expectedDeletedPods     []string
+		expectedRemainingPods   []string
+	}{
+		{
+			description:    "no driver loaded",
+			nodeLabels:     defaultTestOperandLabels(),
+			expectedLabels: defaultTestOperandLabels(),
+		},
+		{
+			description: "no driver loaded with DRA",
+			nodeLabels: withLabels(defaultTestOperandLabels(), map[string]string{
+				nvidiaDRADriverDeployLabel:    "true",
+				nvidiaDRAValidatorDeployLabel: "true",
+			}),
+			expectedLabels: withLabels(defaultTestOperandLabels(), map[string]string{
+				nvidiaDRADriverDeployLabel:    "true",
+				nvidiaDRAValidatorDeployLabel: "true",
+			}),
+		},
+		{
+			description:         "driver loaded with the desired configuration skips uninstall",
+			nodeLabels:          defaultTestOperandLabels(),
+			expectedUnprotected: true,
+			objects:             []runtime.Object{newTestGPUPod("training")},
+			loadedModules:       []string{"nvidia", "nvidia_uvm"},
+			storedDigest:        testConfigDigest,
+			expectedLabels:      defaultTestOperandLabels(),
+			// GPU workloads are left undisturbed
+			expectedRemainingPods: []string{"training"},
+		},
+		{
+			description:             "force reinstall uninstalls the driver with the desired configuration",
+			nodeLabels:              defaultTestOperandLabels(),
+			loadedModules:           []string{"nvidia", "nvidia_uvm"},
+			storedDigest:            testConfigDigest,
+			modifyConfig:            func(c *config) { c.forceReinstall = true },
+			expectedLabels:          defaultTestOperandLabels(),
+			expectedUnloadedModules: []string{"nvidia_uvm", "nvidia"},
+		},
+		{
+			description:             "GPU pods are evicted before the driver is unloaded",
+			nodeLabels:              defaultTestOperandLabels(),
+			objects:                 []runtime.Object{newTestGPUPod("training")},
+			loadedModules:           []string{"nvidia", "nvidia_modeset"},
+			storedDigest:            "previous-digest",
+			expectedLabels:          defaultTestOperandLabels(),
+			expectedUnloadedModules: []string{"nvidia_modeset", "nvidia"},
+			expectedDeletedPods:     []string{"training"},
+		},
+		{
+			description:             "deferred rescheduling waits for the driver to be ready",
+			nodeLabels:              defaultTestOperandLabels(),
+			loadedModules:           []string{"nvidia"},
+			storedDigest:            "previous-digest",
+			modifyConfig:            func(c *config) { c.deferReschedule = true },
+			expectedLabels:          defaultTestOperandLabels(),
+			expectedUnloadedModules: []string{"nvidia"},
+		},
+		{
+			description:         "failing pre-operand-eviction hook leaves the node unprotected",
+			nodeLabels:          defaultTestOperandLabels(),
+			loadedModules:       []string{"nvidia"},
+			storedDigest:        "previous-digest",
+			failingHook:         hooks.PhasePreOperandEviction,
+			expectedError:       true,
+			expectedLabels:      defaultTestOperandLabels(),
+			expectedUnprotected: true,
+		},
+		{
+			description:             "failing pre-reschedule hook restores the operand labels",
+			nodeLabels:              defaultTestOperandLabels(),
+			loadedModules:           []string{"nvidia"},
+			storedDigest:            "previous-digest",
+			failingHook:             hooks.PhasePreReschedule,
+			expectedError:           true,
+			expectedLabels:          defaultTestOperandLabels(),
+			expectedUnloadedModules: []string{"nvidia"},
+		},
+		{
+			description: "failing pre-reschedule hook without a driver loaded restores the DRA labels",
+			nodeLabels: withLabels(defaultTestOperandLabels(), map[string]string{
+				nvidiaDRADriverDeployLabel: "true",
+			}),
+			failingHook:   hooks.PhasePreReschedule,
+			expectedError: true,
+			expectedLabels: withLabels(defaultTestOperandLabels(), map[string]string{
+				nvidiaDRADriverDeployLabel: "true",
+			}),
+		},
+		{
+			description:    "failing pre-reschedule hook without uninstall restores the operand labels",
+			nodeLabels:     defaultTestOperandLabels(),
+			loadedModules:  []string{"nvidia"},
+			storedDigest:   testConfigDigest,
+			failingHook:    hooks.PhasePreReschedule,
+			expectedError:  true,
+			expectedLabels: defaultTestOperandLabels(),
+		},
+		{
+			description:             "cordon applied by an admin is left in place",
+			nodeLabels:              defaultTestOperandLabels(),
+			nodeCordoned:            true,
+			objects:                 []runtime.Object{newTestGPUPod("training")},
+			loadedModules:           []string{"nvidia"},
+			expectedLabels:          defaultTestOperandLabels(),
+			expectedCordoned:        true,
+			expectedUnloadedModules: []string{"nvidia"},
+			expectedDeletedPods:     []string{"training"},
+		},
+		{
+			description:             "taint quiesce mode leaves the node schedulable",
+			nodeLabels:              defaultTestOperandLabels(),
+			objects:                 []runtime.Object{newTestGPUPod("training")},
+			loadedModules:           []string{"nvidia"},
+			modifyConfig:            func(c *config) { c.quiesceMode = quiesceModeTaint },
+			expectedLabels:          defaultTestOperandLabels(),
+			expectedUnloadedModules: []string{"nvidia"},
+			expectedDeletedPods:     []string{"training"},
+		},
+		{
+			// The operand labels of the test node are owned by another field manager
+			description:    "conflicting label ownership aborts the upgrade unless forced",
+			nodeLabels:     defaultTestOperandLabels(),
+			loadedModules:  []string{"nvidia"},
+			modifyConfig:   func(c *config) { c.forceLabelOwnership = false },
+			expectedError:  true,
+			expectedLabels: defaultTestOperandLabels(),
+		},
+		{
+			description:             "custom operand label is paused and restored",
+			nodeLabels:              withLabels(defaultTestOperandLabels(), map[string]string{"example.com/gpu-client": "enabled"}),
+			loadedModules:           []string{"nvidia"},
+			modifyConfig:            func(c *config) { c.nodeLabelForGPUPodEviction = "example.com/gpu-client" },
+			expectedLabels:          withLabels(defaultTestOperandLabels(), map[string]string{"example.com/gpu-client": "enabled"}),
+			expectedUnloadedModules: []string{"nvidia"},
+		},
+		{
+			description:             "auto-upgrade policy leaves GPU pod eviction to the GPU Operator",
+			nodeLabels:              defaultTestOperandLabels(),
+			nodeAnnotations:         map[string]string{"nvidia.com/gpu-driver-upgrade-enabled": "true"},
+			objects:                 []runtime.Object{newTestGPUPod("training")},
+			loadedModules:           []string{"nvidia"},
+			expectedLabels:          defaultTestOperandLabels(),
+			expectedUnloadedModules: []string{"nvidia"},
+			expectedRemainingPods:   []string{"training"},
+		},
+		{
+			description:     "DRA claim holders left by the auto-upgrade policy block the uninstall",
+			nodeLabels:      withLabels(defaultTestOperandLabels(), map[string]string{nvidiaDRADriverDeployLabel: "true"}),
+			nodeAnnotations: map[string]string{"nvidia.com/gpu-driver-upgrade-enabled": "true"},
+			objects:         newTestDRAClaimHolder("inference"),
+			loadedModules:   []string{"nvidia"},
+			expectedError:   true,
+			expectedLabels:  withLabels(defaultTestOperandLabels(), map[string]string{nvidiaDRADriverDeployLabel: "true"}),
+		},
+		{
+			description:             "busy driver is unloaded after a node drain",
+			nodeLabels:              defaultTestOperandLabels(),
+			loadedModules:           []string{"nvidia"},
+			busyModules:             map[string]int{"nvidia": 1},
+			expectedLabels:          defaultTestOperandLabels(),
+			expectedUnloadedModules: []string{"nvidia"},
+		},
+		{
+			description:    "busy driver without auto drain restores the operand labels",
+			nodeLabels:     defaultTestOperandLabels(),
+			loadedModules:  []string{"nvidia"},
+			busyModules:    map[string]int{"nvidia": 1},
+			modifyConfig:   func(c *config) { c.enableAutoDrain = false },
+			expectedError:  true,
+			expectedLabels: defaultTestOperandLabels(),
+		},
+		{
+			description:    "node drain leaves GPU pods which are never evicted running",
+			nodeLabels:     defaultTestOperandLabels(),
+			objects:        []runtime.Object{newNeverEvictTestGPUPod("inference"), newTestGPUPod("training")},
+			loadedModules:  []string{"nvidia"},
+			busyModules:    map[string]int{"nvidia": 2},
+			expectedError:  true,
+			expectedLabels: defaultTestOperandLabels(),
+			// The GPU pod kept by the eviction policy still holds the driver
+			expectedDeletedPods:   []string{"training"},
+			expectedRemainingPods: []string{"inference"},
+		},
+		{
+			description:    "driver still busy after a node drain restores the operand labels",
+			nodeLabels:     defaultTestOperandLabels(),
+			loadedModules:  []string{"nvidia"},
+			busyModules:    map[string]int{"nvidia": 2},
+			expectedError:  true,
+			expectedLabels: defaultTestOperandLabels(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			t.Setenv("DRIVER_CONFIG_DIGEST", testConfigDigest)
+
+			node := newTestNode(tc.nodeLabels, tc.nodeAnnotations)
+			node.Spec.Unschedulable = tc.nodeCordoned
+			objects := append([]runtime.Object{node}, tc.objects...)
+			clientset := fake.NewClientset(objects...)
+			// Advertise the core API without the eviction subresource so that the drain
+			// helper falls back to deleting pods, which the fake clientset supports.
+			clientset.Resources = []*metav1.APIResourceList{{
+				GroupVersion: "v1",
+				APIResources: []metav1.APIResource{{Name: "pods", Namespaced: true, Kind: "Pod"}},
+			}}
+
+			h := newFakeHost(tc.loadedModules...)
+			for module, count := range tc.busyModules {
+				h.busyModules[module] = count
+			}
+			dm := newTestDriverManager(t, clientset, h, tc.modifyConfig)
+			if tc.storedDigest != "" {
+				h.files[dm.driverConfigStateFile()] = tc.storedDigest
+			}
+			if tc.failingHook != "" {
+				dm.hookRunner = newFailingHookRunner(t, dm.log, tc.failingHook)
+			}
+			dm.autoscalerProtection = map[string]string{testProtectionAnnotation: "true"}
+			protected := false
+			clientset.PrependReactor("patch", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if strings.Contains(string(action.(k8stesting.PatchAction).GetPatch()), testProtectionAnnotation) {
+					protected = true
+				}
+				return false, nil, nil
+			})
+
+			err := dm.uninstallDriver()
+			if tc.expectedError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			if dm.config.deferReschedule {
+				// The GPU operator components are only rescheduled once the new driver is ready
+				node, err = clientset.CoreV1().Nodes().Get(context.Background(), testNodeName, metav1.GetOptions{})
+				require.NoError(t, err)
+				require.Equal(t, pausedStr, node.Labels[nvidiaDevicePluginDeployLabel])
+				h.files[dm.driverReadyFile()] = ""
+				require.NoError(t, dm.waitForDriverAndReschedule(dm.driverReadyFile(), 0))
+			}
+
+			node, err = clientset.CoreV1().Nodes().Get(context.Background(), testNodeName, metav1.GetOptions{})
+			require.NoError(t, err)
+			for label, value := range tc.expectedLabels {
+				require.Equal(t, value, node.Labels[label], "label %s", label)
+			}
+			require.Equal(t, tc.expectedCordoned, node.Spec.Unschedulable, "cordon of the node")
+			require.NotContains(t, node.Annotations, cordonOwnerAnnotation)
+			require.NotContains(t, node.Annotations, pausedLabelsAnnotation)
+			require.NotContains(t, node.Annotations, testProtectionAnnotation)
+			require.NotContains(t, node.Annotations, autoscalerProtectionAnnotation)
+			require.Empty(t, node.Spec.Taints)
+			if tc.expectedUnprotected {
+				require.False(t, protected, "node protected from autoscaler scale-down")
+			}
+
+			require.Equal(t, tc.expectedUnloadedModules, h.unloadedModules)
+
+			for _, name := range tc.expectedDeletedPods {
+				_, err := clientset.CoreV1().Pods("default").Get(context.Background(), name, metav1.GetOptions{})
+				require.True(t, apierrors.IsNotFound(err), "pod %s was not deleted", name)
+			}
+			for _, name := range tc.expectedRemainingPods {
+				_, err := clientset.CoreV1().Pods("default").Get(context.Background(), name, metav1.GetOptions{})
+				require.NoError(t, err, "pod %s was deleted", name)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/NVIDIA/k8s-driver-manager/internal/maintenance"
+)
+
+const (
+	// maintenanceWindowAnnotation holds a JSON encoded maintenance window for a single node,
+	// e.g. {"schedule":"0 2 * * 6","timezone":"Europe/Berlin","duration":"4h"}
+	maintenanceWindowAnnotation = nvidiaDomainPrefix + "/" + "gpu-driver-maintenance-window"
+
+	maintenanceWindowConditionType corev1.NodeConditionType = "GPUDriverMaintenanceWindow"
+	maintenanceWindowPollInterval                           = time.Minute
+)
+
+// loadMaintenanceWindow returns the maintenance window gating disruptive driver operations on
+// the node, or nil if there is none. A window set through the node annotation takes precedence
+// over the one in the ConfigMap, which takes precedence over the one set through flags.
+func (dm *DriverManager) loadMaintenanceWindow() (*maintenance.Window, error) {
+	annotationValue, err := dm.kubeClient.GetNodeAnnotationValue(dm.config.nodeName, maintenanceWindowAnnotation)
+	if err != nil {
+		return nil, err
+	}
+	if annotationValue != "" {
+		var config maintenance.Config
+		if err := json.Unmarshal([]byte(annotationValue), &config); err != nil {
+			return nil, fmt.Errorf("failed to parse annotation %s: %w", maintenanceWindowAnnotation, err)
+		}
+		return maintenance.NewWindow(config)
+	}
+
+	if dm.config.maintenanceWindowConfigMap != "" {
+		data, err := dm.kubeClient.GetConfigMapData(dm.config.operatorNamespace, dm.config.maintenanceWindowConfigMap)
+		if err != nil {
+			return nil, err
+		}
+		return maintenance.NewWindow(maintenance.Config{
+			Schedule: data["schedule"],
+			Timezone: data["timezone"],
+			Duration: data["duration"],
+		})
+	}
+
+	if dm.config.maintenanceWindowSchedule != "" {
+		return maintenance.NewWindow(maintenance.Config{
+			Schedule: dm.config.maintenanceWindowSchedule,
+			Timezone: dm.config.maintenanceWindowTimezone,
+			Duration: dm.config.maintenanceWindowDuration,
+		})
+	}
+
+	return nil, nil
+}
+
+// waitForMaintenanceWindow holds the disruptive part of the driver upgrade until the node is
+// within its maintenance window, reporting the wait through a node condition. The window is
+// reloaded on every check so that changes to it take effect while waiting.
+func (dm *DriverManager) waitForMaintenanceWindow() error {
+	waiting := false
+	for {
+		window, err := dm.loadMaintenanceWindow()
+		if err != nil {
+			return fmt.Errorf("failed to load maintenance window: %w", err)
+		}
+
+		now := time.Now()
+		if window == nil || window.Contains(now) {
+			if window != nil {
+				dm.log.Infof("Node %s is within its maintenance window %s", dm.config.nodeName, window)
+			}
+			dm.clearMaintenanceWindowCondition()
+			return nil
+		}
+
+		next := window.Next(now)
+		message := fmt.Sprintf("Waiting for maintenance window %s, next window opens at %s", window, next.Format(time.RFC3339))
+		dm.log.Info(message)
+		if !waiting {
+			dm.setMaintenanceWindowCondition(corev1.ConditionTrue, "WaitingForMaintenanceWindow", message)
+			waiting = true
+		}
+
+		wait := maintenanceWindowPollInterval
+		if untilNext := time.Until(next); !next.IsZero() && untilNext < wait {
+			wait = untilNext
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-dm.ctx.Done():
+			timer.Stop()
+			return dm.ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// clearMaintenanceWindowCondition reports that the driver upgrade is no longer waiting for a
+// maintenance window, including a wait left reported by a previous run of driver-manager
+func (dm *DriverManager) clearMaintenanceWindowCondition() {
+	status, err := dm.kubeClient.GetNodeConditionStatus(dm.config.nodeName, maintenanceWindowConditionType)
+	if err != nil {
+		dm.log.Warnf("Failed to get maintenance window condition: %v", err)
+		return
+	}
+	if status == corev1.ConditionTrue {
+		dm.setMaintenanceWindowCondition(corev1.ConditionFalse, "InMaintenanceWindow", "The GPU driver upgrade is proceeding")
+	}
+}
+
+func (dm *DriverManager) setMaintenanceWindowCondition(status corev1.ConditionStatus, reason, message string) {
+	condition := corev1.NodeCondition{
+		Type:    maintenanceWindowConditionType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+	if err := dm.kubeClient.SetNodeCondition(dm.config.nodeName, condition); err != nil {
+		dm.log.Warnf("Failed to report maintenance window condition: %v", err)
+		return
+	}
+	dm.invalidateNodeSnapshot(dm.config.nodeName)
+}
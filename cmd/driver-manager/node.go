@@ -0,0 +1,102 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"errors"
+
+	kube "github.com/NVIDIA/k8s-driver-manager/internal/kubernetes"
+)
+
+// maxNodeUpdateAttempts bounds the number of times an update of a node is computed again
+// because the node was modified concurrently
+const maxNodeUpdateAttempts = 5
+
+// modifyNodeFunc computes the labels and annotations to apply to a node from a snapshot of it
+type modifyNodeFunc func(node *kube.NodeSnapshot) (map[string]string, map[string]*string, error)
+
+// refreshNodeSnapshot reads the node driver-manager runs on and keeps a snapshot of it, which
+// the driver manager works from until the node is modified
+func (dm *DriverManager) refreshNodeSnapshot() (*kube.NodeSnapshot, error) {
+	node, err := dm.kubeClient.GetNodeSnapshot(dm.config.nodeName)
+	if err != nil {
+		return nil, err
+	}
+	dm.node = node
+	return node, nil
+}
+
+// nodeSnapshot returns the snapshot of the node driver-manager runs on, reading the node only
+// if no up-to-date snapshot is kept
+func (dm *DriverManager) nodeSnapshot() (*kube.NodeSnapshot, error) {
+	if dm.node != nil {
+		return dm.node, nil
+	}
+	return dm.refreshNodeSnapshot()
+}
+
+// getNodeSnapshot returns a snapshot of the given node, which is the kept snapshot for the
+// node driver-manager runs on
+func (dm *DriverManager) getNodeSnapshot(nodeName string) (*kube.NodeSnapshot, error) {
+	if nodeName == dm.config.nodeName {
+		return dm.nodeSnapshot()
+	}
+	return dm.kubeClient.GetNodeSnapshot(nodeName)
+}
+
+// invalidateNodeSnapshot drops the kept snapshot after driver-manager modified the node
+// without getting its new state back, e.g. by cordoning or tainting it
+func (dm *DriverManager) invalidateNodeSnapshot(nodeName string) {
+	if nodeName == dm.config.nodeName {
+		dm.node = nil
+	}
+}
+
+// updateNodeMetadata applies the labels and annotations computed by modify from a snapshot of
+// a node, provided the node has not been modified since the snapshot was taken. Otherwise,
+// they are computed again from a new snapshot. Nothing is applied if modify returns neither
+// labels nor annotations.
+func (dm *DriverManager) updateNodeMetadata(node *kube.NodeSnapshot, modify modifyNodeFunc) error {
+	for attempt := 1; ; attempt++ {
+		labels, annotations, err := modify(node)
+		if err != nil {
+			return err
+		}
+		if len(labels) == 0 && len(annotations) == 0 {
+			return nil
+		}
+
+		updated, err := dm.kubeClient.UpdateNodeMetadataAt(node, labels, annotations)
+		if err == nil {
+			if node.Name == dm.config.nodeName {
+				dm.node = updated
+			}
+			return nil
+		}
+		if !errors.Is(err, kube.ErrNodeModified) || attempt == maxNodeUpdateAttempts {
+			return err
+		}
+
+		dm.log.Infof("Node %s has been modified since it was read, updating it again from its current state", node.Name)
+		dm.invalidateNodeSnapshot(node.Name)
+		if node, err = dm.getNodeSnapshot(node.Name); err != nil {
+			return err
+		}
+	}
+}
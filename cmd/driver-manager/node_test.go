@@ -0,0 +1,62 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPauseOperandsOnConcurrentlyModifiedNode(t *testing.T) {
+	node := newTestNode(map[string]string{nvidiaDevicePluginDeployLabel: "true"}, nil)
+	node.ResourceVersion = "1"
+	clientset := fake.NewClientset(node)
+	dm := newTestDriverManager(t, clientset, newFakeHost(), nil)
+
+	require.NoError(t, dm.fetchCurrentLabels())
+	require.Equal(t, "true", dm.components.pluginDeployed)
+
+	// The GPU Operator enables GFD on the node after driver-manager read it
+	node, err := clientset.CoreV1().Nodes().Get(context.Background(), testNodeName, metav1.GetOptions{})
+	require.NoError(t, err)
+	node.ResourceVersion = "2"
+	node.Labels[nvidiaGFDDeployLabel] = "true"
+	_, err = clientset.CoreV1().Nodes().Update(context.Background(), node, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, dm.pauseOperands([]string{nvidiaDevicePluginDeployLabel, nvidiaGFDDeployLabel}))
+
+	node, err = clientset.CoreV1().Nodes().Get(context.Background(), testNodeName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, pausedStr, node.Labels[nvidiaDevicePluginDeployLabel])
+	require.Equal(t, pausedStr, node.Labels[nvidiaGFDDeployLabel])
+
+	recorded := make(map[string]string)
+	require.NoError(t, json.Unmarshal([]byte(node.Annotations[pausedLabelsAnnotation]), &recorded))
+	require.Equal(t, map[string]string{
+		nvidiaDevicePluginDeployLabel: "true",
+		nvidiaGFDDeployLabel:          "true",
+	}, recorded)
+	require.Equal(t, node.Labels, dm.node.Labels)
+}
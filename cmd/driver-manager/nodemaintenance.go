@@ -0,0 +1,101 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	kube "github.com/NVIDIA/k8s-driver-manager/internal/kubernetes"
+)
+
+const (
+	// drainBackendKubectl drains the node with the kubectl drain library
+	drainBackendKubectl = "kubectl"
+	// drainBackendNodeMaintenance requests the drain of the node from the medik8s Node
+	// Maintenance Operator through a NodeMaintenance custom resource
+	drainBackendNodeMaintenance = "node-maintenance"
+
+	nodeMaintenanceNamePrefix = "nvidia-driver-upgrade-"
+	nodeMaintenanceReason     = "NVIDIA driver upgrade"
+)
+
+// validateDrainBackend checks the drain backend of the configuration. The Node Maintenance
+// Operator drains the whole node with its own drain settings, so a pod selector cannot be
+// honoured and the force and emptyDir options are ignored.
+func validateDrainBackend(cfg *config, log *logrus.Logger) error {
+	switch cfg.drainBackend {
+	case drainBackendKubectl:
+		return nil
+	case drainBackendNodeMaintenance:
+	default:
+		return fmt.Errorf("unsupported drain backend %q", cfg.drainBackend)
+	}
+
+	if cfg.drainPodSelectorLabel != "" {
+		return fmt.Errorf("drain pod selector %q is not supported by the %s drain backend, which drains the whole node", cfg.drainPodSelectorLabel, drainBackendNodeMaintenance)
+	}
+	if cfg.drainUseForce || cfg.drainDeleteEmptyDirData {
+		log.Warnf("The drain-use-force and drain-delete-emptydir-data options are ignored by the %s drain backend", drainBackendNodeMaintenance)
+	}
+	return nil
+}
+
+// nodeMaintenanceName returns the name of the NodeMaintenance driver-manager creates for a node
+func nodeMaintenanceName(nodeName string) string {
+	return nodeMaintenanceNamePrefix + nodeName
+}
+
+// drainNode drains the current node with the configured drain backend
+func (dm *DriverManager) drainNode(drainOpts kube.DrainOptions) error {
+	if dm.config.drainBackend == drainBackendNodeMaintenance {
+		// The Node Maintenance Operator evicts every pod, including those the GPU pod
+		// eviction policy keeps
+		kept, err := dm.kubeClient.ListGPUPodsKeptByEvictionPolicy(dm.config.nodeName, drainOpts.EvictionPolicy)
+		if err != nil {
+			return fmt.Errorf("failed to list GPU pods kept by the eviction policy: %w", err)
+		}
+		if len(kept) > 0 {
+			return fmt.Errorf("the %s drain backend would evict GPU pod(s) kept by the eviction policy: %s", drainBackendNodeMaintenance, strings.Join(kept, ", "))
+		}
+		return dm.kubeClient.RequestNodeMaintenance(nodeMaintenanceName(dm.config.nodeName), dm.config.nodeName, nodeMaintenanceReason, drainOpts.Timeout)
+	}
+	return dm.kubeClient.DrainNode(dm.config.nodeName, drainOpts)
+}
+
+// endNodeMaintenance deletes the NodeMaintenance driver-manager created for a node, if any,
+// so that the Node Maintenance Operator uncordons it. NodeMaintenances are only requested by
+// the auto-drain fallback.
+func (dm *DriverManager) endNodeMaintenance(nodeName string) error {
+	if dm.config.drainBackend != drainBackendNodeMaintenance || !dm.config.enableAutoDrain {
+		return nil
+	}
+	return dm.kubeClient.DeleteNodeMaintenance(nodeMaintenanceName(nodeName))
+}
+
+// unquiesceNodeAndEndMaintenance lifts the cordon and the taint driver-manager applied to a
+// node, then ends the NodeMaintenance it requested. A failure to end the NodeMaintenance does
+// not keep the cordon and the taint in place.
+func (dm *DriverManager) unquiesceNodeAndEndMaintenance(nodeName string) error {
+	err := dm.unquiesceNode(nodeName)
+	return errors.Join(err, dm.endNodeMaintenance(nodeName))
+}
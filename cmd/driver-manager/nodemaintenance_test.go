@@ -0,0 +1,181 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	kube "github.com/NVIDIA/k8s-driver-manager/internal/kubernetes"
+)
+
+func TestValidateDrainBackend(t *testing.T) {
+	testCases := []struct {
+		description     string
+		cfg             config
+		expectedError   string
+		expectedWarning bool
+	}{
+		{
+			description: "kubectl with a pod selector",
+			cfg: config{
+				drainBackend:          drainBackendKubectl,
+				drainPodSelectorLabel: "app=training",
+				drainUseForce:         true,
+			},
+		},
+		{
+			description: "node-maintenance",
+			cfg:         config{drainBackend: drainBackendNodeMaintenance},
+		},
+		{
+			description: "node-maintenance with a pod selector",
+			cfg: config{
+				drainBackend:          drainBackendNodeMaintenance,
+				drainPodSelectorLabel: "app=training",
+			},
+			expectedError: `drain pod selector "app=training" is not supported by the node-maintenance drain backend`,
+		},
+		{
+			description: "node-maintenance with force and emptyDir deletion",
+			cfg: config{
+				drainBackend:            drainBackendNodeMaintenance,
+				drainUseForce:           true,
+				drainDeleteEmptyDirData: true,
+			},
+			expectedWarning: true,
+		},
+		{
+			description:   "unsupported backend",
+			cfg:           config{drainBackend: "eviction"},
+			expectedError: `unsupported drain backend "eviction"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			var output bytes.Buffer
+			log := logrus.New()
+			log.SetOutput(&output)
+
+			err := validateDrainBackend(&tc.cfg, log)
+			if tc.expectedError != "" {
+				require.ErrorContains(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			if tc.expectedWarning {
+				require.Contains(t, output.String(), "are ignored by the node-maintenance drain backend")
+			} else {
+				require.Empty(t, output.String())
+			}
+		})
+	}
+}
+
+func TestDrainNodeMaintenanceKeepsGPUPods(t *testing.T) {
+	clientset := fake.NewClientset(newTestNode(defaultTestOperandLabels(), nil), newNeverEvictTestGPUPod("inference"))
+	dm := newTestDriverManager(t, clientset, newFakeHost(), func(cfg *config) {
+		cfg.drainBackend = drainBackendNodeMaintenance
+	})
+
+	err := dm.drainNode(kube.DrainOptions{EvictionPolicy: dm.gpuPodEvictionPolicy()})
+	require.ErrorContains(t, err, "would evict GPU pod(s) kept by the eviction policy: default/inference")
+}
+
+// newNodeMaintenanceTestDriverManager returns a driver manager using the node-maintenance drain
+// backend, whose NodeMaintenances are drained as soon as they are created
+func newNodeMaintenanceTestDriverManager(t *testing.T, clientset *fake.Clientset, h host) (*DriverManager, *dynamicfake.FakeDynamicClient) {
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{kube.NodeMaintenanceResource: "NodeMaintenanceList"})
+	dynamicClient.PrependReactor("create", "nodemaintenances", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		obj := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		require.NoError(t, unstructured.SetNestedField(obj.Object, "Succeeded", "status", "phase"))
+		return false, nil, nil
+	})
+
+	dm := newTestDriverManager(t, clientset, h, func(cfg *config) {
+		cfg.drainBackend = drainBackendNodeMaintenance
+	})
+	kubeClient, err := kube.NewClientFromClientset(dm.ctx, clientset, dm.log,
+		kube.WithPollInterval(10*time.Millisecond),
+		kube.WithForceFieldOwnership(dm.config.forceLabelOwnership),
+		kube.WithDynamicClient(dynamicClient))
+	require.NoError(t, err)
+	dm.kubeClient = kubeClient
+	return dm, dynamicClient
+}
+
+func TestNodeMaintenanceEndsAfterReschedule(t *testing.T) {
+	t.Setenv("DRIVER_CONFIG_DIGEST", testConfigDigest)
+	clientset := fake.NewClientset(newTestNode(defaultTestOperandLabels(), nil))
+	h := newFakeHost("nvidia")
+	h.busyModules["nvidia"] = 1
+	dm, dynamicClient := newNodeMaintenanceTestDriverManager(t, clientset, h)
+
+	var labelsAtDeletion map[string]string
+	dynamicClient.PrependReactor("delete", "nodemaintenances", func(k8stesting.Action) (bool, runtime.Object, error) {
+		node, err := clientset.CoreV1().Nodes().Get(context.Background(), testNodeName, metav1.GetOptions{})
+		require.NoError(t, err)
+		labelsAtDeletion = node.Labels
+		return false, nil, nil
+	})
+
+	require.NoError(t, dm.uninstallDriver())
+	require.Equal(t, []string{"nvidia"}, h.unloadedModules)
+
+	// The GPU operator components are rescheduled before the maintenance ends
+	require.NotNil(t, labelsAtDeletion, "NodeMaintenance was not deleted")
+	for label, value := range defaultTestOperandLabels() {
+		require.Equal(t, value, labelsAtDeletion[label], "label %s", label)
+	}
+	_, err := dynamicClient.Resource(kube.NodeMaintenanceResource).Get(context.Background(), nodeMaintenanceName(testNodeName), metav1.GetOptions{})
+	require.True(t, apierrors.IsNotFound(err), "NodeMaintenance was not deleted")
+}
+
+func TestUnquiesceNodeAndEndMaintenance(t *testing.T) {
+	clientset := fake.NewClientset(newTestNode(defaultTestOperandLabels(), nil))
+	dm, dynamicClient := newNodeMaintenanceTestDriverManager(t, clientset, newFakeHost())
+	dynamicClient.PrependReactor("delete", "nodemaintenances", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(kube.NodeMaintenanceResource.GroupResource(), nodeMaintenanceName(testNodeName), nil)
+	})
+
+	require.NoError(t, dm.quiesceNode(cordonReasonGPUPodEviction))
+
+	// The node is uncordoned even though the NodeMaintenance cannot be deleted
+	err := dm.unquiesceNodeAndEndMaintenance(testNodeName)
+	require.True(t, apierrors.IsForbidden(err), "unexpected error: %v", err)
+	node, err := clientset.CoreV1().Nodes().Get(context.Background(), testNodeName, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.False(t, node.Spec.Unschedulable)
+	require.NotContains(t, node.Annotations, cordonOwnerAnnotation)
+}
@@ -0,0 +1,180 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// operand is a GPU operator component whose pods driver-manager waits on to terminate
+// before the driver is unloaded
+type operand struct {
+	// name identifies the operand in the termination timeout configuration
+	name string
+	// app is the value of the app label of the operand pods
+	app string
+	// deployed returns the value of the deploy label of an optional operand; required
+	// operands are always waited on
+	deployed func(*componentState) string
+}
+
+// operandRegistry lists the operands in the order their termination is waited on. The DRA
+// kubelet-plugin is drained separately after all other operands (see evictKubeletPlugin).
+var operandRegistry = []operand{
+	// The ClusterPolicy and GPUCluster validators intentionally share this pod
+	// label so the upgrade controller and driver-manager use the same readiness
+	// and shutdown gate.
+	{name: "operator-validator", app: "nvidia-operator-validator"},
+	{name: "container-toolkit", app: "nvidia-container-toolkit-daemonset"},
+	{name: "device-plugin", app: "nvidia-device-plugin-daemonset"},
+	{name: "gpu-feature-discovery", app: "gpu-feature-discovery"},
+	{name: "dcgm-exporter", app: "nvidia-dcgm-exporter"},
+	{name: "dcgm", app: "nvidia-dcgm"},
+	{name: "dcgm-exporter-dra", app: "nvidia-dcgm-exporter-dra"},
+	{name: "dcgm-dra", app: "nvidia-dcgm-dra"},
+	{name: "mig-manager", app: "nvidia-mig-manager", deployed: func(c *componentState) string { return c.migManagerDeployed }},
+	{name: "sandbox-validator", app: "nvidia-sandbox-validator", deployed: func(c *componentState) string { return c.sandboxValidatorDeployed }},
+	{name: "sandbox-device-plugin", app: "nvidia-sandbox-device-plugin-daemonset", deployed: func(c *componentState) string { return c.sandboxPluginDeployed }},
+	{name: "vgpu-device-manager", app: "nvidia-vgpu-device-manager", deployed: func(c *componentState) string { return c.vgpuDeviceManagerDeployed }},
+}
+
+const (
+	// gpuClientOperandName identifies the pods selecting nvidia.com/gpu.deploy.client in the
+	// termination timeout configuration
+	gpuClientOperandName = "gpu-client"
+	// draDriverOperand is the DRA kubelet-plugin
+	draDriverOperandName = "dra-driver"
+	draDriverOperandApp  = "nvidia-dra-driver-kubelet-plugin"
+)
+
+// parseOperandTimeouts parses per-operand termination timeouts given as name=duration
+func parseOperandTimeouts(values []string) (map[string]time.Duration, error) {
+	known := map[string]bool{gpuClientOperandName: true, draDriverOperandName: true}
+	for _, o := range operandRegistry {
+		known[o.name] = true
+	}
+
+	timeouts := make(map[string]time.Duration)
+	for _, value := range values {
+		name, duration, ok := strings.Cut(value, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid operand termination timeout %q, expected <operand>=<duration>", value)
+		}
+		if !known[name] {
+			return nil, fmt.Errorf("invalid operand termination timeout %q: unknown operand %q", value, name)
+		}
+		timeout, err := time.ParseDuration(duration)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid operand termination timeout %q: the duration must be positive", value)
+		}
+		timeouts[name] = timeout
+	}
+	return timeouts, nil
+}
+
+// operandTimeout returns the time the named operand is given to terminate
+func (dm *DriverManager) operandTimeout(name string) time.Duration {
+	if timeout, ok := dm.operandTimeouts[name]; ok {
+		return timeout
+	}
+	return dm.config.operandTerminationTimeout
+}
+
+// waitForOperandTermination waits for the pods of an operand to terminate within its timeout.
+// The error reports whether the operand exceeded its own timeout or the upgrade deadline.
+func (dm *DriverManager) waitForOperandTermination(name string, wait func(timeout time.Duration) error) error {
+	timeout := dm.operandTimeout(name)
+	dm.log.Infof("Waiting up to %s for %s to shutdown", timeout, name)
+	err := wait(timeout)
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(dm.ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("upgrade deadline of %s exceeded while waiting for %s to shutdown: %w", dm.config.upgradeDeadline, name, err)
+	} else if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("%s did not shutdown within %s: %w", name, timeout, err)
+	} else {
+		err = fmt.Errorf("failed to wait for %s to shutdown: %w", name, err)
+	}
+	dm.log.Error(err)
+	return err
+}
+
+func (dm *DriverManager) waitForPodsToTerminate() error {
+	namespace := dm.config.operatorNamespace
+	nodeName := dm.config.nodeName
+
+	for _, o := range operandRegistry {
+		if o.deployed != nil && o.deployed(dm.components) == "" {
+			continue
+		}
+		selectorMap := map[string]string{
+			"app": o.app,
+		}
+		err := dm.waitForOperandTermination(o.name, func(timeout time.Duration) error {
+			return dm.kubeClient.WaitForPodTermination(selectorMap, namespace, nodeName, timeout)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Wait for any pods whose parent controller uses nvidia.com/gpu.deploy.client as a nodeSelector key.
+	if dm.components.gpuClientsDeployed != "" {
+		dm.log.Infof("Waiting for any daemon set pods with nodeSelector key %s to terminate", nvidiaGPUClientDeployLabel)
+		err := dm.waitForOperandTermination(gpuClientOperandName, func(timeout time.Duration) error {
+			return dm.kubeClient.WaitForPodsWithNodeSelector(nodeName, nvidiaGPUClientDeployLabel, timeout)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// startUpgradeDeadline bounds the rest of the upgrade by the configured upgrade deadline. All
+// waits are cancelled once it expires, which aborts the upgrade through cleanupOnFailure.
+func (dm *DriverManager) startUpgradeDeadline() {
+	if dm.config.upgradeDeadline <= 0 || dm.stopUpgradeDeadline != nil {
+		return
+	}
+
+	dm.log.Infof("The driver upgrade must complete within %s", dm.config.upgradeDeadline)
+	ctx, cancel := context.WithTimeout(dm.ctx, dm.config.upgradeDeadline)
+	originalCtx, originalClient := dm.ctx, dm.kubeClient
+	dm.ctx, dm.kubeClient = ctx, dm.kubeClient.WithContext(ctx)
+	dm.stopUpgradeDeadline = func() {
+		cancel()
+		dm.ctx, dm.kubeClient = originalCtx, originalClient
+		dm.stopUpgradeDeadline = nil
+	}
+}
+
+// endUpgradeDeadline stops bounding the driver manager by the upgrade deadline
+func (dm *DriverManager) endUpgradeDeadline() {
+	if dm.stopUpgradeDeadline != nil {
+		dm.stopUpgradeDeadline()
+	}
+}
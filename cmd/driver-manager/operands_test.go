@@ -0,0 +1,128 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseOperandTimeouts(t *testing.T) {
+	testCases := []struct {
+		description      string
+		values           []string
+		expectedError    bool
+		expectedTimeouts map[string]time.Duration
+	}{
+		{
+			description:      "empty",
+			expectedTimeouts: map[string]time.Duration{},
+		},
+		{
+			description: "registry, GPU client and DRA driver operands",
+			values:      []string{"dcgm-exporter=2m", "gpu-client=10m", "dra-driver=30s"},
+			expectedTimeouts: map[string]time.Duration{
+				"dcgm-exporter": 2 * time.Minute,
+				"gpu-client":    10 * time.Minute,
+				"dra-driver":    30 * time.Second,
+			},
+		},
+		{description: "missing duration", values: []string{"dcgm-exporter"}, expectedError: true},
+		{description: "unknown operand", values: []string{"nvidia-dcgm-exporter=2m"}, expectedError: true},
+		{description: "invalid duration", values: []string{"dcgm=soon"}, expectedError: true},
+		{description: "zero duration", values: []string{"dcgm=0s"}, expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			timeouts, err := parseOperandTimeouts(tc.values)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedTimeouts, timeouts)
+		})
+	}
+}
+
+func TestUninstallDriverOperandTermination(t *testing.T) {
+	testCases := []struct {
+		description   string
+		modifyConfig  func(*config)
+		expectedError string
+	}{
+		{
+			description: "operand exceeds its timeout",
+			modifyConfig: func(cfg *config) {
+				cfg.operandTerminationTimeout = time.Hour
+				cfg.operandTerminationTimeouts = *cli.NewStringSlice("device-plugin=50ms")
+			},
+			expectedError: "device-plugin did not shutdown within 50ms",
+		},
+		{
+			description: "operand exceeds the upgrade deadline",
+			modifyConfig: func(cfg *config) {
+				cfg.operandTerminationTimeout = time.Hour
+				cfg.upgradeDeadline = 100 * time.Millisecond
+				cfg.shutdownRollbackTimeout = 10 * time.Second
+			},
+			expectedError: "upgrade deadline of 100ms exceeded while waiting for device-plugin to shutdown",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			t.Setenv("DRIVER_CONFIG_DIGEST", testConfigDigest)
+
+			// The fake clientset does not act on the paused labels, so the device plugin
+			// never terminates
+			devicePlugin := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: operatorNamespace,
+					Name:      "nvidia-device-plugin-daemonset-abcde",
+					Labels:    map[string]string{"app": "nvidia-device-plugin-daemonset"},
+				},
+				Spec: corev1.PodSpec{NodeName: testNodeName},
+			}
+			clientset := fake.NewClientset(newTestNode(defaultTestOperandLabels(), nil), devicePlugin)
+			dm := newTestDriverManager(t, clientset, newFakeHost("nvidia"), tc.modifyConfig)
+			operandTimeouts, err := parseOperandTimeouts(dm.config.operandTerminationTimeouts.Value())
+			require.NoError(t, err)
+			dm.operandTimeouts = operandTimeouts
+
+			err = dm.uninstallDriver()
+			require.ErrorContains(t, err, tc.expectedError)
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+
+			node, err := clientset.CoreV1().Nodes().Get(context.Background(), testNodeName, metav1.GetOptions{})
+			require.NoError(t, err)
+			for label, value := range defaultTestOperandLabels() {
+				require.Equal(t, value, node.Labels[label], "label %s", label)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"path/filepath"
+)
+
+// Default locations of the host filesystems as seen from the driver-manager container.
+// They can be pointed at a mounted host snapshot or a fake tree through flags.
+const (
+	defaultHostRoot  = "/host"
+	defaultSysfsRoot = "/sys"
+	defaultProcRoot  = "/proc"
+	defaultRunDir    = "/run"
+)
+
+// driverRoot is the mount point of the rootfs of the driver container
+func (dm *DriverManager) driverRoot() string {
+	return filepath.Join(dm.config.runDir, "nvidia", "driver")
+}
+
+// driverPIDFile is the PID file of the driver container
+func (dm *DriverManager) driverPIDFile() string {
+	return filepath.Join(dm.config.runDir, "nvidia", "nvidia-driver.pid")
+}
+
+// driverConfigStateFile holds the digest of the configuration of the loaded driver
+func (dm *DriverManager) driverConfigStateFile() string {
+	return filepath.Join(dm.config.runDir, "nvidia", "nvidia-driver.state")
+}
+
+// bootIDStateFile holds the boot ID of the kernel of the node when driver-manager last paused
+// its GPU operator components or rolled out its driver, which tells a reboot of the node from
+// a restart of the driver container
+func (dm *DriverManager) bootIDStateFile() string {
+	return filepath.Join(dm.config.runDir, "nvidia", "nvidia-driver.boot-id")
+}
+
+// driverReadyFile is created by the driver container once the driver is loaded
+func (dm *DriverManager) driverReadyFile() string {
+	return filepath.Join(dm.config.runDir, "nvidia", "validations", ".driver-ctr-ready")
+}
+
+// mofedReadyFile is created by the MOFED driver container once the driver is installed
+func (dm *DriverManager) mofedReadyFile() string {
+	return filepath.Join(dm.config.runDir, "mellanox", "drivers", ".driver-ready")
+}
+
+// rebootRequiredFile is the /var/run/reboot-required sentinel file marking the host as
+// requiring a reboot, as watched by reboot daemons such as kured. /var/run is a symlink to
+// /run on the host, which cannot be followed under the host root.
+func (dm *DriverManager) rebootRequiredFile() string {
+	return filepath.Join(dm.config.hostRoot, "run", "reboot-required")
+}
+
+// sysfsPath returns the path of a file under sysfs
+func (dm *DriverManager) sysfsPath(elem ...string) string {
+	return filepath.Join(append([]string{dm.config.sysfsRoot}, elem...)...)
+}
+
+// procPath returns the path of a file under procfs
+func (dm *DriverManager) procPath(elem ...string) string {
+	return filepath.Join(append([]string{dm.config.procRoot}, elem...)...)
+}
@@ -0,0 +1,169 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+
+	kube "github.com/NVIDIA/k8s-driver-manager/internal/kubernetes"
+)
+
+// pausedLabelsAnnotation records the original values of the operand deploy labels paused by
+// driver-manager as a JSON object, so that resuming restores them exactly. Paused labels are
+// set to pausedStr.
+const pausedLabelsAnnotation = nvidiaDomainPrefix + "/" + "gpu-driver-upgrade-paused-labels"
+
+// legacyPausedPattern matches the suffix appended to label values by the previous encoding
+// of paused operands, e.g. "true_paused-for-driver-upgrade"
+var legacyPausedPattern = regexp.MustCompile(pausedStr + "_?")
+
+// getPausedLabels returns the original values of the operand labels paused on the node
+func (dm *DriverManager) getPausedLabels(node *kube.NodeSnapshot) map[string]string {
+	recorded := make(map[string]string)
+	value, ok := node.Annotations[pausedLabelsAnnotation]
+	if !ok {
+		return recorded
+	}
+	if err := json.Unmarshal([]byte(value), &recorded); err != nil {
+		dm.log.Warnf("Ignoring invalid %s annotation on node %s: %v", pausedLabelsAnnotation, node.Name, err)
+		return make(map[string]string)
+	}
+	return recorded
+}
+
+// pauseOperands pauses the GPU operator components selecting the given deploy labels on the
+// node and records the original values of the labels
+func (dm *DriverManager) pauseOperands(labels []string) error {
+	node, err := dm.nodeSnapshot()
+	if err != nil {
+		return err
+	}
+
+	// Record the boot the GPU operator components are paused in before the checkpoint
+	dm.recordBootID()
+	return dm.updateNodeMetadata(node, func(node *kube.NodeSnapshot) (map[string]string, map[string]*string, error) {
+		paused, recorded := pauseLabels(node.Labels, labels, dm.getPausedLabels(node))
+		if len(paused) == 0 {
+			return nil, nil, nil
+		}
+
+		record, err := json.Marshal(recorded)
+		if err != nil {
+			return nil, nil, err
+		}
+		annotations := map[string]*string{pausedLabelsAnnotation: ptr(string(record))}
+		if _, ok := node.Annotations[pausedAtAnnotation]; !ok {
+			annotations[pausedAtAnnotation] = ptr(time.Now().UTC().Format(time.RFC3339))
+		}
+
+		dm.operandsPaused = true
+		return paused, annotations, nil
+	})
+}
+
+// resumeOperands restores the operand labels paused on the node. It returns the number of
+// labels restored.
+func (dm *DriverManager) resumeOperands(node *kube.NodeSnapshot) (int, error) {
+	var resumed map[string]string
+	err := dm.updateNodeMetadata(node, func(node *kube.NodeSnapshot) (map[string]string, map[string]*string, error) {
+		resumed = resumeLabels(node.Labels, dm.getPausedLabels(node), dm.isOperandLabel)
+		_, recorded := node.Annotations[pausedLabelsAnnotation]
+		_, pausedAt := node.Annotations[pausedAtAnnotation]
+		if len(resumed) == 0 && !recorded && !pausedAt {
+			return nil, nil, nil
+		}
+
+		annotations := map[string]*string{
+			pausedLabelsAnnotation: nil,
+			pausedAtAnnotation:     nil,
+		}
+		return resumed, annotations, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(resumed), nil
+}
+
+// pauseLabels returns the label values pausing the given labels of a node and the updated
+// record of their original values. Labels which are unset or false select no operand and are
+// left as they are; labels which are already paused keep their recorded original value. Label
+// values are only decoded from the previous encoding if no original value is recorded.
+func pauseLabels(nodeLabels map[string]string, labels []string, recorded map[string]string) (map[string]string, map[string]string) {
+	paused := make(map[string]string)
+	updated := make(map[string]string)
+	for label, value := range recorded {
+		updated[label] = value
+	}
+
+	for _, label := range labels {
+		value := nodeLabels[label]
+		if value == "" || value == "false" {
+			continue
+		}
+		if _, ok := recorded[label]; ok {
+			if value == pausedStr {
+				continue
+			}
+		} else if strings.Contains(value, pausedStr) {
+			// Migrate a label paused with the previous encoding
+			value = legacyResumeValue(value)
+		}
+		updated[label] = value
+		paused[label] = pausedStr
+	}
+	return paused, updated
+}
+
+// resumeLabels returns the label values restoring the paused labels of a node: labels with a
+// recorded original value get it back if they are still paused, and operand labels paused with
+// the previous encoding are decoded. Labels changed by someone else since they were paused are
+// left as they are.
+func resumeLabels(nodeLabels map[string]string, recorded map[string]string, isOperandLabel func(string) bool) map[string]string {
+	resumed := make(map[string]string)
+	for label, value := range recorded {
+		if nodeLabels[label] != pausedStr {
+			continue
+		}
+		resumed[label] = value
+	}
+	for label, value := range nodeLabels {
+		if _, ok := recorded[label]; ok || !isOperandLabel(label) || !strings.Contains(value, pausedStr) {
+			continue
+		}
+		resumed[label] = legacyResumeValue(value)
+	}
+	return resumed
+}
+
+// legacyResumeValue decodes a label value paused with the previous encoding, which replaced
+// "true" with pausedStr and appended "_" + pausedStr to any other value
+func legacyResumeValue(value string) string {
+	if value == pausedStr {
+		return "true"
+	}
+	return strings.Trim(legacyPausedPattern.ReplaceAllString(value, ""), "_")
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
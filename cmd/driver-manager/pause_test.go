@@ -0,0 +1,133 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPauseAndResumeLabels(t *testing.T) {
+	longValue := strings.Repeat("v", 63)
+	isOperandLabel := func(label string) bool { return strings.HasPrefix(label, operandDeployLabelPrefix) }
+
+	testCases := []struct {
+		description      string
+		nodeLabels       map[string]string
+		recorded         map[string]string
+		expectedPaused   map[string]string
+		expectedRecorded map[string]string
+		// modify changes the paused labels before they are resumed, as an admin would
+		modify          map[string]string
+		expectedResumed map[string]string
+	}{
+		{
+			description: "values are restored exactly",
+			nodeLabels: map[string]string{
+				nvidiaDevicePluginDeployLabel: "true",
+				nvidiaGFDDeployLabel:          longValue,
+				nvidiaDCGMDeployLabel:         "keep_" + pausedStr,
+				nvidiaDCGMExporterDeployLabel: "false",
+			},
+			// A stale record does not cause the current value to be decoded
+			recorded: map[string]string{nvidiaDCGMDeployLabel: "stale"},
+			expectedPaused: map[string]string{
+				nvidiaDevicePluginDeployLabel: pausedStr,
+				nvidiaGFDDeployLabel:          pausedStr,
+				nvidiaDCGMDeployLabel:         pausedStr,
+			},
+			expectedRecorded: map[string]string{
+				nvidiaDevicePluginDeployLabel: "true",
+				nvidiaGFDDeployLabel:          longValue,
+				nvidiaDCGMDeployLabel:         "keep_" + pausedStr,
+			},
+			expectedResumed: map[string]string{
+				nvidiaDevicePluginDeployLabel: "true",
+				nvidiaGFDDeployLabel:          longValue,
+				nvidiaDCGMDeployLabel:         "keep_" + pausedStr,
+			},
+		},
+		{
+			description:      "already paused labels keep their recorded value",
+			nodeLabels:       map[string]string{nvidiaDevicePluginDeployLabel: pausedStr},
+			recorded:         map[string]string{nvidiaDevicePluginDeployLabel: "custom"},
+			expectedPaused:   map[string]string{},
+			expectedRecorded: map[string]string{nvidiaDevicePluginDeployLabel: "custom"},
+			expectedResumed:  map[string]string{nvidiaDevicePluginDeployLabel: "custom"},
+		},
+		{
+			description: "legacy encoding is migrated",
+			nodeLabels: map[string]string{
+				nvidiaDevicePluginDeployLabel: pausedStr,
+				nvidiaGFDDeployLabel:          "custom_" + pausedStr,
+			},
+			expectedPaused: map[string]string{
+				nvidiaDevicePluginDeployLabel: pausedStr,
+				nvidiaGFDDeployLabel:          pausedStr,
+			},
+			expectedRecorded: map[string]string{
+				nvidiaDevicePluginDeployLabel: "true",
+				nvidiaGFDDeployLabel:          "custom",
+			},
+			expectedResumed: map[string]string{
+				nvidiaDevicePluginDeployLabel: "true",
+				nvidiaGFDDeployLabel:          "custom",
+			},
+		},
+		{
+			description:      "labels changed while paused are left alone",
+			nodeLabels:       map[string]string{nvidiaDevicePluginDeployLabel: "true", nvidiaGFDDeployLabel: "true"},
+			expectedPaused:   map[string]string{nvidiaDevicePluginDeployLabel: pausedStr, nvidiaGFDDeployLabel: pausedStr},
+			expectedRecorded: map[string]string{nvidiaDevicePluginDeployLabel: "true", nvidiaGFDDeployLabel: "true"},
+			modify:           map[string]string{nvidiaDevicePluginDeployLabel: "false"},
+			expectedResumed:  map[string]string{nvidiaGFDDeployLabel: "true"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			var labels []string
+			for label := range tc.nodeLabels {
+				labels = append(labels, label)
+			}
+			recorded := tc.recorded
+			if recorded == nil {
+				recorded = map[string]string{}
+			}
+
+			paused, updated := pauseLabels(tc.nodeLabels, labels, recorded)
+			require.Equal(t, tc.expectedPaused, paused)
+			require.Equal(t, tc.expectedRecorded, updated)
+
+			nodeLabels := make(map[string]string)
+			for label, value := range tc.nodeLabels {
+				nodeLabels[label] = value
+			}
+			for label, value := range paused {
+				nodeLabels[label] = value
+			}
+			for label, value := range tc.modify {
+				nodeLabels[label] = value
+			}
+			require.Equal(t, tc.expectedResumed, resumeLabels(nodeLabels, updated, isOperandLabel))
+		})
+	}
+}
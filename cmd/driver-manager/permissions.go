@@ -0,0 +1,132 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	kube "github.com/NVIDIA/k8s-driver-manager/internal/kubernetes"
+)
+
+// requiredPermission is a permission the configured upgrade flow needs and the feature needing it
+type requiredPermission struct {
+	kube.Permission
+	feature string
+}
+
+// requiredPermissions returns the permissions driver-manager needs to upgrade the driver of
+// the node with the configured features
+func (dm *DriverManager) requiredPermissions(draDriverDeployed bool) []requiredPermission {
+	cfg := dm.config
+	var required []requiredPermission
+	add := func(feature string, permissions ...kube.Permission) {
+		for _, p := range permissions {
+			required = append(required, requiredPermission{Permission: p, feature: feature})
+		}
+	}
+
+	add("GPU operator component management",
+		kube.Permission{Verb: "get", Resource: "nodes"},
+		kube.Permission{Verb: "patch", Resource: "nodes"},
+		kube.Permission{Verb: "list", Resource: "pods", Namespace: cfg.operatorNamespace},
+		// The termination of the GPU clients is awaited across all namespaces
+		kube.Permission{Verb: "list", Resource: "pods"},
+	)
+
+	evictionEnabled := cfg.enableGPUPodEviction || cfg.enableAutoDrain
+	if evictionEnabled {
+		if cfg.quiesceMode == quiesceModeTaint {
+			add("node taint", kube.Permission{Verb: "update", Resource: "nodes"})
+		}
+		add("GPU pod eviction",
+			kube.Permission{Verb: "get", Resource: "pods"},
+			kube.Permission{Verb: "delete", Resource: "pods"},
+			kube.Permission{Verb: "create", Resource: "pods", Subresource: "eviction"},
+			kube.Permission{Verb: "get", Group: "apps", Resource: "daemonsets"},
+		)
+	}
+	if cfg.enableAutoDrain && cfg.drainBackend == drainBackendNodeMaintenance {
+		for _, verb := range []string{"get", "create", "delete"} {
+			add("NodeMaintenance drain", kube.Permission{Verb: verb, Group: "nodemaintenance.medik8s.io", Resource: "nodemaintenances"})
+		}
+	}
+	if cfg.enableGPUPodEviction && cfg.gpuPodEvictionNoticePeriod > 0 {
+		add("GPU pod eviction notice", kube.Permission{Verb: "patch", Resource: "pods"})
+		if cfg.gpuPodEvictionNoticeCondition {
+			add("GPU pod eviction notice", kube.Permission{Verb: "patch", Resource: "pods", Subresource: "status"})
+		}
+	}
+	if draDriverDeployed {
+		add("DRA", kube.Permission{Verb: "get", Group: "resource.k8s.io", Resource: "resourceclaims"})
+	}
+
+	if cfg.maxConcurrentUpgrades > 0 {
+		for _, verb := range []string{"get", "create", "update"} {
+			add("upgrade slots", kube.Permission{Verb: verb, Group: "coordination.k8s.io", Resource: "leases", Namespace: cfg.operatorNamespace})
+		}
+	}
+	if cfg.maintenanceWindowConfigMap != "" {
+		add("maintenance window", kube.Permission{Verb: "get", Resource: "configmaps", Namespace: cfg.operatorNamespace})
+	}
+	if cfg.maintenanceWindowSchedule != "" || cfg.maintenanceWindowConfigMap != "" {
+		add("maintenance window", kube.Permission{Verb: "patch", Resource: "nodes", Subresource: "status"})
+	}
+
+	return required
+}
+
+// checkPermissions refuses to start an upgrade which would fail midway, after the GPU
+// operator components have been paused, for lack of a permission
+func (dm *DriverManager) checkPermissions() error {
+	dm.log.Info("Checking the permissions of driver-manager")
+
+	draDriverDeployed := false
+	node, err := dm.nodeSnapshot()
+	switch {
+	case err == nil:
+		draDriverDeployed = node.Labels[nvidiaDRADriverDeployLabel] != ""
+	case !apierrors.IsForbidden(err):
+		return err
+	}
+
+	required := dm.requiredPermissions(draDriverDeployed)
+	permissions := make([]kube.Permission, len(required))
+	features := make(map[kube.Permission]string)
+	for i, r := range required {
+		permissions[i] = r.Permission
+		features[r.Permission] = r.feature
+	}
+
+	missing, err := dm.kubeClient.MissingPermissions(permissions)
+	if err != nil {
+		return err
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	descriptions := make([]string, len(missing))
+	for i, p := range missing {
+		descriptions[i] = fmt.Sprintf("%s (%s)", p, features[p])
+	}
+	return fmt.Errorf("driver-manager is missing the following permissions: %s", strings.Join(descriptions, ", "))
+}
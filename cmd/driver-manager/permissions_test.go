@@ -0,0 +1,119 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestCheckPermissions(t *testing.T) {
+	// allowAllBut grants every permission but those with the given resources
+	allowAllBut := func(resources ...string) k8stesting.ReactionFunc {
+		return func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+			attributes := review.Spec.ResourceAttributes
+			resource := attributes.Resource
+			if attributes.Subresource != "" {
+				resource += "/" + attributes.Subresource
+			}
+			review.Status.Allowed = true
+			for _, r := range resources {
+				if r == resource {
+					review.Status.Allowed = false
+				}
+			}
+			return true, review, nil
+		}
+	}
+
+	testCases := []struct {
+		description   string
+		labels        map[string]string
+		modify        func(*config)
+		reactor       k8stesting.ReactionFunc
+		expectedError string
+	}{
+		{
+			description: "all permissions granted",
+			reactor:     allowAllBut(),
+		},
+		{
+			description:   "eviction not allowed",
+			reactor:       allowAllBut("pods/eviction"),
+			expectedError: "driver-manager is missing the following permissions: create pods/eviction (GPU pod eviction)",
+		},
+		{
+			description: "eviction disabled",
+			modify: func(cfg *config) {
+				cfg.enableGPUPodEviction = false
+				cfg.enableAutoDrain = false
+			},
+			reactor: allowAllBut("pods/eviction", "daemonsets"),
+		},
+		{
+			description:   "resource claims of the DRA driver",
+			labels:        map[string]string{nvidiaDRADriverDeployLabel: "true"},
+			reactor:       allowAllBut("resourceclaims"),
+			expectedError: "get resourceclaims.resource.k8s.io (DRA)",
+		},
+		{
+			description: "NodeMaintenance drain backend",
+			modify: func(cfg *config) {
+				cfg.drainBackend = drainBackendNodeMaintenance
+			},
+			reactor:       allowAllBut("nodemaintenances"),
+			expectedError: "get nodemaintenances.nodemaintenance.medik8s.io (NodeMaintenance drain), ",
+		},
+		{
+			description: "upgrade slots and eviction notice",
+			modify: func(cfg *config) {
+				cfg.maxConcurrentUpgrades = 1
+				cfg.gpuPodEvictionNoticePeriod = time.Minute
+				cfg.gpuPodEvictionNoticeCondition = true
+			},
+			reactor: allowAllBut("leases", "pods/status"),
+			expectedError: "patch pods/status (GPU pod eviction notice), " +
+				"get leases.coordination.k8s.io in namespace gpu-operator (upgrade slots), " +
+				"create leases.coordination.k8s.io in namespace gpu-operator (upgrade slots), " +
+				"update leases.coordination.k8s.io in namespace gpu-operator (upgrade slots)",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			clientset := fake.NewClientset(newTestNode(tc.labels, nil))
+			clientset.PrependReactor("create", "selfsubjectaccessreviews", tc.reactor)
+			dm := newTestDriverManager(t, clientset, newFakeHost(), tc.modify)
+
+			err := dm.checkPermissions()
+			if tc.expectedError == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.expectedError)
+		})
+	}
+}
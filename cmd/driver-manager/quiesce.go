@@ -0,0 +1,224 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	kube "github.com/NVIDIA/k8s-driver-manager/internal/kubernetes"
+)
+
+const (
+	// quiesceModeCordon keeps all new pods off the node during a driver upgrade
+	quiesceModeCordon = "cordon"
+	// quiesceModeTaint keeps only the new pods which do not tolerate quiesceTaintKey off the
+	// node during a driver upgrade, so that e.g. CPU-only workloads can be exempted
+	quiesceModeTaint = "taint"
+
+	quiesceTaintKey           = nvidiaDomainPrefix + "/" + "gpu-driver-upgrade"
+	defaultQuiesceTaintEffect = string(corev1.TaintEffectNoSchedule)
+)
+
+// cordonOwnerAnnotation marks a cordon applied by driver-manager. Its value is a JSON encoded
+// cordonOwnership.
+const cordonOwnerAnnotation = nvidiaDomainPrefix + "/" + "gpu-driver-upgrade-cordon"
+
+const cordonReasonGPUPodEviction = "evicting GPU pods for a driver upgrade"
+
+// cordonOwnership records why and when driver-manager cordoned a node
+type cordonOwnership struct {
+	Reason    string    `json:"reason"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// getCordonOwnership returns the cordon ownership recorded on the node, or nil if the node
+// was not cordoned by driver-manager
+func getCordonOwnership(annotations map[string]string) *cordonOwnership {
+	value, ok := annotations[cordonOwnerAnnotation]
+	if !ok {
+		return nil
+	}
+	ownership := &cordonOwnership{}
+	if err := json.Unmarshal([]byte(value), ownership); err != nil {
+		// The annotation is only ever written by driver-manager, so keep treating the
+		// cordon as owned even if its details cannot be decoded
+		return &cordonOwnership{}
+	}
+	return ownership
+}
+
+// validateQuiesceConfig checks the quiesce mode and taint effect of the configuration
+func validateQuiesceConfig(cfg *config) error {
+	switch cfg.quiesceMode {
+	case quiesceModeCordon, quiesceModeTaint:
+	default:
+		return fmt.Errorf("unsupported quiesce mode %q", cfg.quiesceMode)
+	}
+	switch corev1.TaintEffect(cfg.quiesceTaintEffect) {
+	case corev1.TaintEffectNoSchedule, corev1.TaintEffectNoExecute:
+	default:
+		return fmt.Errorf("unsupported quiesce taint effect %q", cfg.quiesceTaintEffect)
+	}
+	return nil
+}
+
+// quiesceNode keeps new pods off the node for the driver upgrade, either by cordoning it or
+// by tainting it depending on the configured quiesce mode
+func (dm *DriverManager) quiesceNode(reason string) error {
+	if dm.config.quiesceMode == quiesceModeTaint {
+		return dm.taintNode()
+	}
+	return dm.cordonNode(reason)
+}
+
+// unquiesceNode lifts the cordon and the taint driver-manager applied to a node. The cordon
+// and the taint are both checked regardless of the configured quiesce mode, so that changing
+// the mode during an upgrade does not leave either behind.
+func (dm *DriverManager) unquiesceNode(nodeName string) error {
+	node, err := dm.getNodeSnapshot(nodeName)
+	if err != nil {
+		return err
+	}
+	for _, taint := range node.Taints {
+		if taint.Key != quiesceTaintKey {
+			continue
+		}
+		if err := dm.kubeClient.RemoveNodeTaint(nodeName, quiesceTaintKey); err != nil {
+			return fmt.Errorf("failed to remove taint %s from node %s: %w", quiesceTaintKey, nodeName, err)
+		}
+		dm.invalidateNodeSnapshot(nodeName)
+		break
+	}
+	return dm.uncordonNode(nodeName)
+}
+
+// taintNode applies the quiesce taint to the node. A NoExecute taint also evicts the running
+// pods which do not tolerate it, so the NoSchedule effect is used instead if any GPU operator
+// component on the node does not tolerate the taint: evicting those would abort the upgrade.
+func (dm *DriverManager) taintNode() error {
+	taint := corev1.Taint{
+		Key:    quiesceTaintKey,
+		Effect: corev1.TaintEffect(dm.config.quiesceTaintEffect),
+	}
+
+	if taint.Effect == corev1.TaintEffectNoExecute {
+		pods, err := dm.kubeClient.ListNodePods(dm.config.operatorNamespace, dm.config.nodeName)
+		if err != nil {
+			return err
+		}
+		for _, pod := range pods {
+			if !toleratesTaint(pod, taint) {
+				dm.log.Warnf("Pod %s/%s does not tolerate the %s taint, falling back to the %s effect",
+					pod.Namespace, pod.Name, taint.ToString(), corev1.TaintEffectNoSchedule)
+				taint.Effect = corev1.TaintEffectNoSchedule
+				break
+			}
+		}
+	}
+
+	if err := dm.kubeClient.AddNodeTaint(dm.config.nodeName, taint); err != nil {
+		return err
+	}
+	dm.invalidateNodeSnapshot(dm.config.nodeName)
+	return nil
+}
+
+func toleratesTaint(pod corev1.Pod, taint corev1.Taint) bool {
+	for _, toleration := range pod.Spec.Tolerations {
+		if toleration.ToleratesTaint(klog.Background(), &taint, false) {
+			return true
+		}
+	}
+	return false
+}
+
+// cordonNode cordons the node and records that driver-manager owns the cordon. A node which
+// is already cordoned by someone else is left as is, so that the cordon is not taken over and
+// later lifted by driver-manager.
+func (dm *DriverManager) cordonNode(reason string) error {
+	node, err := dm.nodeSnapshot()
+	if err != nil {
+		return err
+	}
+
+	// Record the ownership before cordoning, so that a crash in between cannot leave an
+	// unowned cordon behind
+	cordon := false
+	err = dm.updateNodeMetadata(node, func(node *kube.NodeSnapshot) (map[string]string, map[string]*string, error) {
+		cordon = false
+		if node.Unschedulable {
+			if getCordonOwnership(node.Annotations) == nil {
+				dm.log.Infof("Node %s is already cordoned by another actor, leaving its cordon in place", node.Name)
+			}
+			return nil, nil, nil
+		}
+
+		data, err := json.Marshal(cordonOwnership{Reason: reason, Timestamp: time.Now().UTC().Truncate(time.Second)})
+		if err != nil {
+			return nil, nil, err
+		}
+		cordon = true
+		return nil, map[string]*string{cordonOwnerAnnotation: ptr(string(data))}, nil
+	})
+	if err != nil || !cordon {
+		return err
+	}
+
+	if err := dm.kubeClient.CordonNode(dm.config.nodeName); err != nil {
+		return err
+	}
+	dm.invalidateNodeSnapshot(dm.config.nodeName)
+	return nil
+}
+
+// uncordonNode uncordons a node if it was cordoned by driver-manager and clears the record of
+// its ownership. Cordons applied by anyone else are left in place.
+func (dm *DriverManager) uncordonNode(nodeName string) error {
+	node, err := dm.getNodeSnapshot(nodeName)
+	if err != nil {
+		return err
+	}
+	if getCordonOwnership(node.Annotations) == nil {
+		if node.Unschedulable {
+			dm.log.Infof("Node %s was not cordoned by driver-manager, leaving it cordoned", nodeName)
+		}
+		return nil
+	}
+
+	if node.Unschedulable {
+		if err := dm.kubeClient.UncordonNode(nodeName); err != nil {
+			return err
+		}
+		dm.invalidateNodeSnapshot(nodeName)
+		if node, err = dm.getNodeSnapshot(nodeName); err != nil {
+			return err
+		}
+	}
+	return dm.updateNodeMetadata(node, func(node *kube.NodeSnapshot) (map[string]string, map[string]*string, error) {
+		if _, ok := node.Annotations[cordonOwnerAnnotation]; !ok {
+			return nil, nil, nil
+		}
+		return nil, map[string]*string{cordonOwnerAnnotation: nil}, nil
+	})
+}
@@ -0,0 +1,91 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestQuiesceNodeWithTaint(t *testing.T) {
+	newOperandPod := func(name string, tolerations ...corev1.Toleration) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: operatorNamespace, Name: name},
+			Spec:       corev1.PodSpec{NodeName: testNodeName, Tolerations: tolerations},
+		}
+	}
+	tolerateAll := corev1.Toleration{Operator: corev1.TolerationOpExists}
+
+	testCases := []struct {
+		description    string
+		effect         corev1.TaintEffect
+		objects        []runtime.Object
+		expectedEffect corev1.TaintEffect
+	}{
+		{
+			description:    "NoSchedule",
+			effect:         corev1.TaintEffectNoSchedule,
+			objects:        []runtime.Object{newOperandPod("nvidia-driver")},
+			expectedEffect: corev1.TaintEffectNoSchedule,
+		},
+		{
+			description:    "NoExecute tolerated by all operands",
+			effect:         corev1.TaintEffectNoExecute,
+			objects:        []runtime.Object{newOperandPod("nvidia-driver", tolerateAll)},
+			expectedEffect: corev1.TaintEffectNoExecute,
+		},
+		{
+			description: "NoExecute not tolerated by an operand",
+			effect:      corev1.TaintEffectNoExecute,
+			objects: []runtime.Object{
+				newOperandPod("nvidia-driver", tolerateAll),
+				newOperandPod("nvidia-dcgm", corev1.Toleration{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists}),
+			},
+			expectedEffect: corev1.TaintEffectNoSchedule,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			clientset := fake.NewClientset(append(tc.objects, newTestNode(nil, nil))...)
+			dm := newTestDriverManager(t, clientset, newFakeHost(), func(cfg *config) {
+				cfg.quiesceMode = quiesceModeTaint
+				cfg.quiesceTaintEffect = string(tc.effect)
+			})
+
+			require.NoError(t, dm.quiesceNode(cordonReasonGPUPodEviction))
+			node, err := clientset.CoreV1().Nodes().Get(t.Context(), testNodeName, metav1.GetOptions{})
+			require.NoError(t, err)
+			require.False(t, node.Spec.Unschedulable)
+			require.Len(t, node.Spec.Taints, 1)
+			require.Equal(t, quiesceTaintKey, node.Spec.Taints[0].Key)
+			require.Equal(t, tc.expectedEffect, node.Spec.Taints[0].Effect)
+
+			require.NoError(t, dm.unquiesceNode(testNodeName))
+			node, err = clientset.CoreV1().Nodes().Get(t.Context(), testNodeName, metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Empty(t, node.Spec.Taints)
+		})
+	}
+}
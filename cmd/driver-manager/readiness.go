@@ -0,0 +1,158 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	defaultDriverReadyTimeout = 30 * time.Minute
+	driverReadyPollInterval   = 5 * time.Second
+)
+
+func newWaitForDriverCommand(cfg *config, components *componentState, log *logrus.Logger) *cli.Command {
+	var readyFile string
+	var timeout time.Duration
+	return &cli.Command{
+		Name:    "wait_for_driver_and_reschedule",
+		Aliases: []string{"wait-for-driver-and-reschedule"},
+		Usage:   "Wait for the driver container to be ready before rescheduling the GPU operator components deferred by a driver upgrade",
+		Before:  requireNodeName(cfg),
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "driver-ready-file",
+				Usage:       "File created by the driver container once the driver is loaded. Defaults to nvidia/validations/.driver-ctr-ready under the run directory",
+				Destination: &readyFile,
+				EnvVars:     []string{"DRIVER_READY_FILE"},
+			},
+			&cli.DurationFlag{
+				Name:        "timeout",
+				Usage:       "Reschedule the GPU operator components anyway if the driver is not ready within this duration. Zero waits indefinitely",
+				Destination: &timeout,
+				EnvVars:     []string{"DRIVER_READY_TIMEOUT"},
+				Value:       defaultDriverReadyTimeout,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			dm, err := newDriverManager(c.Context, cfg, components, log)
+			if err != nil {
+				return fmt.Errorf("failed to create driver manager: %w", err)
+			}
+			if readyFile == "" {
+				readyFile = dm.driverReadyFile()
+			}
+			return dm.waitForDriverAndReschedule(readyFile, timeout)
+		},
+	}
+}
+
+// rescheduleOrDefer reschedules the GPU operator components after the driver has been
+// uninstalled, unless rescheduling is deferred to wait_for_driver_and_reschedule, which only
+// does so once the new driver is ready. The ready file of the previous driver container is
+// removed then, so that it is not mistaken for the new driver being ready.
+func (dm *DriverManager) rescheduleOrDefer() error {
+	if dm.config.deferReschedule {
+		if err := dm.host.removeFile(dm.driverReadyFile()); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove the driver ready file %s: %w", dm.driverReadyFile(), err)
+		}
+		dm.log.Info("Deferring the rescheduling of the GPU operator components until the driver container is ready")
+		return nil
+	}
+	return dm.rescheduleGPUOperatorComponents()
+}
+
+// waitForDriverAndReschedule waits for the driver container to report the new driver as ready
+// and reschedules the GPU operator components. They are rescheduled even if the driver is not
+// ready within the timeout or driver-manager is terminated, so that the node is not left
+// without them.
+func (dm *DriverManager) waitForDriverAndReschedule(readyFile string, timeout time.Duration) error {
+	waitErr := dm.waitForDriverReady(readyFile, timeout)
+	if waitErr != nil {
+		dm.log.Warnf("Rescheduling the GPU operator components although the driver is not ready: %v", waitErr)
+	}
+
+	defer dm.useRollbackContext()()
+	if err := dm.rescheduleGPUOperatorComponents(); err != nil {
+		return fmt.Errorf("failed to reschedule GPU operator components: %w", err)
+	}
+	return waitErr
+}
+
+// waitForDriverReady blocks until the ready file exists and, if the desired driver version
+// is known, the loaded driver module has that version, so that a ready file left behind by the
+// previous driver container is not mistaken for the new driver being ready. A zero timeout
+// waits indefinitely.
+func (dm *DriverManager) waitForDriverReady(readyFile string, timeout time.Duration) error {
+	dm.log.Infof("Waiting for the NVIDIA driver to be ready")
+
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	for {
+		reason := dm.driverNotReadyReason(readyFile)
+		if reason == "" {
+			dm.log.Info("The NVIDIA driver is ready")
+			return nil
+		}
+
+		wait := driverReadyPollInterval
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return fmt.Errorf("driver not ready within %s: %s", timeout, reason)
+			}
+			wait = min(wait, remaining)
+		}
+		dm.log.Infof("Waiting for the NVIDIA driver to be ready: %s", reason)
+		if err := dm.sleep(wait); err != nil {
+			return err
+		}
+	}
+}
+
+// driverNotReadyReason describes why the driver is not ready yet, or returns an empty string
+// if it is
+func (dm *DriverManager) driverNotReadyReason(readyFile string) string {
+	if !dm.host.pathExists(readyFile) {
+		return fmt.Sprintf("%s does not exist", readyFile)
+	}
+	if dm.config.driverVersion == "" {
+		return ""
+	}
+
+	data, err := dm.host.readFile(dm.sysfsPath("module", "nvidia", "version"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "the nvidia module is not loaded"
+		}
+		return fmt.Sprintf("failed to read the version of the nvidia module: %v", err)
+	}
+	if version := strings.TrimSpace(string(data)); version != dm.config.driverVersion {
+		return fmt.Sprintf("the loaded nvidia module has version %s instead of %s", version, dm.config.driverVersion)
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWaitForDriverAndReschedule(t *testing.T) {
+	const (
+		readyFile     = "/run/nvidia/validations/.driver-ctr-ready"
+		versionFile   = "/sys/module/nvidia/version"
+		driverVersion = "580.95.05"
+	)
+
+	testCases := []struct {
+		description   string
+		files         map[string]string
+		driverVersion string
+		// deferred defers the rescheduling as driver-manager does after unloading the driver
+		deferred      bool
+		expectedError bool
+	}{
+		{
+			description: "driver ready",
+			files:       map[string]string{readyFile: ""},
+		},
+		{
+			description:   "desired driver version loaded",
+			files:         map[string]string{readyFile: "", versionFile: driverVersion + "\n"},
+			driverVersion: driverVersion,
+		},
+		{
+			description:   "ready file left behind by the previous driver",
+			files:         map[string]string{readyFile: "", versionFile: "570.172.08\n"},
+			driverVersion: driverVersion,
+			expectedError: true,
+		},
+		{
+			description:   "stale ready file without a desired driver version",
+			files:         map[string]string{readyFile: ""},
+			deferred:      true,
+			expectedError: true,
+		},
+		{
+			description:   "driver not ready",
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			node := newTestNode(
+				map[string]string{nvidiaDevicePluginDeployLabel: pausedStr},
+				map[string]string{pausedLabelsAnnotation: `{"` + nvidiaDevicePluginDeployLabel + `":"true"}`},
+			)
+			clientset := fake.NewClientset(node)
+			h := newFakeHost()
+			for path, content := range tc.files {
+				h.files[path] = content
+			}
+			dm := newTestDriverManager(t, clientset, h, func(cfg *config) {
+				cfg.driverVersion = tc.driverVersion
+			})
+
+			if tc.deferred {
+				dm.config.deferReschedule = true
+				require.NoError(t, dm.rescheduleOrDefer())
+			}
+
+			err := dm.waitForDriverAndReschedule(dm.driverReadyFile(), 50*time.Millisecond)
+			if tc.expectedError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			// The GPU operator components are rescheduled even if the driver is not ready
+			node, err = clientset.CoreV1().Nodes().Get(context.Background(), testNodeName, metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, "true", node.Labels[nvidiaDevicePluginDeployLabel])
+			require.NotContains(t, node.Annotations, pausedLabelsAnnotation)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	kube "github.com/NVIDIA/k8s-driver-manager/internal/kubernetes"
+	"github.com/NVIDIA/k8s-driver-manager/internal/linuxutils"
+)
+
+const (
+	// rebootRequiredLabel is set to "true" on a node whose driver could not be unloaded and
+	// which requires a reboot to complete the driver upgrade, and to "false" once rebooted
+	rebootRequiredLabel = nvidiaDomainPrefix + "/" + "gpu-driver-upgrade-reboot-required"
+	// rebootRequiredAnnotation holds the JSON encoded rebootRequirement of such a node
+	rebootRequiredAnnotation = rebootRequiredLabel
+)
+
+// rebootRequirement records why a node requires a reboot
+type rebootRequirement struct {
+	Reason    string    `json:"reason"`
+	Holders   []string  `json:"holders,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// handleUnloadFailure handles the failure to unload the driver. By default, the upgrade is
+// rolled back and fails. With the reboot fallback, the node is marked as requiring a reboot
+// before failing, and a reboot daemon such as kured takes over the upgrade.
+func (dm *DriverManager) handleUnloadFailure(unloadErr error) error {
+	if !dm.config.rebootOnUnloadFailure {
+		dm.cleanupOnFailure()
+		return unloadErr
+	}
+
+	if err := dm.requireReboot(unloadErr); err != nil {
+		dm.log.Errorf("Failed to mark node %s as requiring a reboot: %v", dm.config.nodeName, err)
+		dm.cleanupOnFailure()
+		return unloadErr
+	}
+
+	// Reschedule the GPU operator components, which keep working with the loaded driver
+	// until the reboot
+	if dm.config.rebootKeepCordoned {
+		defer dm.useRollbackContext()()
+		if err := dm.rescheduleGPUOperatorComponents(); err != nil {
+			dm.log.Warnf("Failed to reschedule GPU operator components: %v", err)
+		}
+		dm.releaseUpgradeSlot()
+	} else {
+		dm.cleanupOnFailure()
+	}
+	return fmt.Errorf("node %s requires a reboot: %w", dm.config.nodeName, unloadErr)
+}
+
+// requireReboot marks the node as requiring a reboot with the sentinel file watched by reboot
+// daemons such as kured, and with a label and an annotation recording the reason and the
+// holders of the driver modules
+func (dm *DriverManager) requireReboot(reason error) error {
+	requirement := rebootRequirement{
+		Reason:    reason.Error(),
+		Holders:   dm.driverModuleHolders(),
+		Timestamp: time.Now().UTC().Truncate(time.Second),
+	}
+	dm.log.Warnf("Node %s requires a reboot to complete the driver upgrade: %s", dm.config.nodeName, requirement.Reason)
+	for _, holder := range requirement.Holders {
+		dm.log.Warnf("Driver module in use: %s", holder)
+	}
+
+	sentinel := fmt.Sprintf("The NVIDIA driver could not be unloaded: %s\n", requirement.Reason)
+	if err := dm.host.writeFile(dm.rebootRequiredFile(), []byte(sentinel)); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dm.rebootRequiredFile(), err)
+	}
+
+	data, err := json.Marshal(requirement)
+	if err != nil {
+		return err
+	}
+	node, err := dm.nodeSnapshot()
+	if err != nil {
+		return err
+	}
+	return dm.updateNodeMetadata(node, func(*kube.NodeSnapshot) (map[string]string, map[string]*string, error) {
+		return map[string]string{rebootRequiredLabel: "true"}, map[string]*string{rebootRequiredAnnotation: ptr(string(data))}, nil
+	})
+}
+
+// clearRebootRequirement removes the marks of a reboot requirement once the node has rebooted
+func (dm *DriverManager) clearRebootRequirement(node *kube.NodeSnapshot) error {
+	if err := dm.host.removeFile(dm.rebootRequiredFile()); err != nil && !os.IsNotExist(err) {
+		dm.log.Warnf("Failed to remove %s: %v", dm.rebootRequiredFile(), err)
+	}
+	if node.Labels[rebootRequiredLabel] != "true" {
+		return nil
+	}
+
+	dm.log.Infof("Node %s has been rebooted as required to complete the driver upgrade", node.Name)
+	err := dm.updateNodeMetadata(node, func(node *kube.NodeSnapshot) (map[string]string, map[string]*string, error) {
+		if node.Labels[rebootRequiredLabel] != "true" {
+			return nil, nil, nil
+		}
+		return map[string]string{rebootRequiredLabel: "false"}, map[string]*string{rebootRequiredAnnotation: nil}, nil
+	})
+	if err != nil {
+		return err
+	}
+	return dm.unquiesceNodeAndEndMaintenance(node.Name)
+}
+
+// driverModuleHolders describes the loaded NVIDIA driver modules which are in use
+func (dm *DriverManager) driverModuleHolders() []string {
+	km := linuxutils.NewKernelModules(dm.log, linuxutils.WithProcRoot(dm.config.procRoot))
+	modules, err := km.Get("nvidia")
+	if err != nil {
+		dm.log.Warnf("Failed to list kernel modules: %v", err)
+		return nil
+	}
+
+	var holders []string
+	for _, m := range modules {
+		if m.RefCount == 0 {
+			continue
+		}
+		usedBy := "processes"
+		if len(m.UsedBy) > 0 {
+			usedBy = strings.Join(m.UsedBy, ",")
+		}
+		holders = append(holders, fmt.Sprintf("%s (refcount %d, used by %s)", m.Name, m.RefCount, usedBy))
+	}
+	return holders
+}
@@ -0,0 +1,89 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRebootOnUnloadFailure(t *testing.T) {
+	const bootIDFile = "/proc/sys/kernel/random/boot_id"
+
+	testCases := []struct {
+		description      string
+		keepCordoned     bool
+		expectedCordoned bool
+	}{
+		{
+			description: "node uncordoned",
+		},
+		{
+			description:      "node kept cordoned until the reboot",
+			keepCordoned:     true,
+			expectedCordoned: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			t.Setenv("DRIVER_CONFIG_DIGEST", testConfigDigest)
+
+			clientset := fake.NewClientset(newTestNode(defaultTestOperandLabels(), nil))
+			h := newFakeHost("nvidia")
+			h.busyModules["nvidia"] = 2
+			h.files[bootIDFile] = "first-boot"
+			dm := newTestDriverManager(t, clientset, h, func(cfg *config) {
+				cfg.rebootOnUnloadFailure = true
+				cfg.rebootKeepCordoned = tc.keepCordoned
+			})
+			err := dm.uninstallDriver()
+			require.ErrorContains(t, err, "requires a reboot")
+
+			require.Contains(t, h.files, dm.rebootRequiredFile())
+			node, err := clientset.CoreV1().Nodes().Get(context.Background(), testNodeName, metav1.GetOptions{})
+			require.NoError(t, err)
+			for label, value := range defaultTestOperandLabels() {
+				require.Equal(t, value, node.Labels[label], "label %s", label)
+			}
+			require.Equal(t, tc.expectedCordoned, node.Spec.Unschedulable, "cordon of the node")
+			var requirement rebootRequirement
+			require.NoError(t, json.Unmarshal([]byte(node.Annotations[rebootRequiredAnnotation]), &requirement))
+			require.Contains(t, requirement.Reason, "failed to cleanup NVIDIA driver")
+
+			// The node is rebooted
+			delete(h.files, dm.rebootRequiredFile())
+			h.files[bootIDFile] = "second-boot"
+			dm = newTestDriverManager(t, clientset, h, nil)
+			require.NoError(t, dm.reconcileBoot())
+
+			node, err = clientset.CoreV1().Nodes().Get(context.Background(), testNodeName, metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, "false", node.Labels[rebootRequiredLabel])
+			require.NotContains(t, node.Annotations, rebootRequiredAnnotation)
+			require.False(t, node.Spec.Unschedulable, "node is left cordoned after the reboot")
+			require.NotContains(t, node.Annotations, cordonOwnerAnnotation)
+		})
+	}
+}
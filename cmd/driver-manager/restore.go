@@ -0,0 +1,126 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	kube "github.com/NVIDIA/k8s-driver-manager/internal/kubernetes"
+)
+
+func newRestoreLabelsCommand(cfg *config, components *componentState, log *logrus.Logger) *cli.Command {
+	var selector string
+	var uncordon bool
+	return &cli.Command{
+		Name:    "restore_labels",
+		Aliases: []string{"restore-labels", "recover"},
+		Usage:   "Un-pause the GPU operator component labels left behind by an interrupted driver upgrade",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "selector",
+				Aliases:     []string{"l"},
+				Usage:       "Restore the labels of all nodes matching this label selector instead of only the current node. Mutually exclusive with node-name",
+				Destination: &selector,
+			},
+			&cli.BoolFlag{
+				Name:        "uncordon",
+				Usage:       "Lift the cordon or taint applied by driver-manager after restoring the labels of the nodes",
+				Destination: &uncordon,
+			},
+		},
+		Before: func(c *cli.Context) error {
+			if selector == "" {
+				return requireNodeName(cfg)(c)
+			}
+			if cfg.nodeName != "" {
+				return fmt.Errorf("the selector and node-name flags are mutually exclusive")
+			}
+			return nil
+		},
+		Action: func(c *cli.Context) error {
+			dm, err := newDriverManager(c.Context, cfg, components, log)
+			if err != nil {
+				return fmt.Errorf("failed to create driver manager: %w", err)
+			}
+			return dm.restoreLabels(selector, uncordon)
+		},
+	}
+}
+
+// restoreLabels un-pauses the operand labels on the current node, or on every node matching
+// the selector if one is given, and optionally uncordons them. A failure on one node does not
+// stop the others from being restored.
+func (dm *DriverManager) restoreLabels(selector string, uncordon bool) error {
+	var nodes []*kube.NodeSnapshot
+	if selector != "" {
+		nodeList, err := dm.kubeClient.ListNodes(selector)
+		if err != nil {
+			return err
+		}
+		if len(nodeList) == 0 {
+			dm.log.Warnf("No nodes match selector %q", selector)
+		}
+		for i := range nodeList {
+			nodes = append(nodes, kube.NewNodeSnapshot(&nodeList[i]))
+		}
+	} else {
+		node, err := dm.nodeSnapshot()
+		if err != nil {
+			return err
+		}
+		nodes = []*kube.NodeSnapshot{node}
+	}
+
+	var errs []error
+	for _, node := range nodes {
+		if err := dm.restoreNodeLabels(node, uncordon); err != nil {
+			dm.log.Errorf("Failed to restore node %s: %v", node.Name, err)
+			errs = append(errs, fmt.Errorf("node %s: %w", node.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// restoreNodeLabels restores every paused operand deploy label of the node and the custom
+// operand node selector label, if configured
+func (dm *DriverManager) restoreNodeLabels(node *kube.NodeSnapshot, uncordon bool) error {
+	restored, err := dm.resumeOperands(node)
+	if err != nil {
+		return err
+	}
+	if restored == 0 {
+		dm.log.Infof("No paused GPU operator component labels on node %s", node.Name)
+	} else {
+		dm.log.Infof("Restored %d paused GPU operator component label(s) on node %s", restored, node.Name)
+	}
+
+	if err := dm.unprotectFromScaleDown(node.Name); err != nil {
+		return err
+	}
+
+	if uncordon {
+		if err := dm.unquiesceNodeAndEndMaintenance(node.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
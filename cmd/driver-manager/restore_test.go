@@ -0,0 +1,156 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRestoreLabels(t *testing.T) {
+	newNode := func(name string, unschedulable bool, labels, annotations map[string]string) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels, Annotations: annotations},
+			Spec:       corev1.NodeSpec{Unschedulable: unschedulable},
+		}
+	}
+
+	testCases := []struct {
+		description           string
+		selector              string
+		uncordon              bool
+		expectedNodes         map[string]map[string]string
+		expectedUnschedulable map[string]bool
+	}{
+		{
+			description: "current node only",
+			expectedNodes: map[string]map[string]string{
+				testNodeName: {
+					"gpu":                         "true",
+					nvidiaDevicePluginDeployLabel: "true",
+					nvidiaDCGMExporterDeployLabel: "false",
+					nvidiaGFDDeployLabel:          "custom",
+					"example.com/gpu-workload":    "true",
+					"example.com/unrelated":       "true_" + pausedStr,
+				},
+				"other-node": {
+					"gpu":                         "true",
+					nvidiaDevicePluginDeployLabel: pausedStr,
+				},
+			},
+			expectedUnschedulable: map[string]bool{testNodeName: true, "other-node": true},
+		},
+		{
+			// The cordon of other-node was not applied by driver-manager
+			description: "selector with uncordon",
+			selector:    "gpu=true",
+			uncordon:    true,
+			expectedNodes: map[string]map[string]string{
+				testNodeName: {
+					"gpu":                         "true",
+					nvidiaDevicePluginDeployLabel: "true",
+					nvidiaDCGMExporterDeployLabel: "false",
+					nvidiaGFDDeployLabel:          "custom",
+					"example.com/gpu-workload":    "true",
+					"example.com/unrelated":       "true_" + pausedStr,
+				},
+				"other-node": {
+					"gpu":                         "true",
+					nvidiaDevicePluginDeployLabel: "true",
+				},
+			},
+			expectedUnschedulable: map[string]bool{testNodeName: false, "other-node": true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			clientset := fake.NewClientset(
+				newNode(testNodeName, true, map[string]string{
+					"gpu":                         "true",
+					nvidiaDevicePluginDeployLabel: pausedStr,
+					nvidiaDCGMExporterDeployLabel: "false",
+					nvidiaGFDDeployLabel:          "custom_" + pausedStr,
+					"example.com/gpu-workload":    pausedStr,
+					"example.com/unrelated":       "true_" + pausedStr,
+				}, map[string]string{cordonOwnerAnnotation: `{"reason":"upgrade"}`}),
+				newNode("other-node", true, map[string]string{
+					"gpu":                         "true",
+					nvidiaDevicePluginDeployLabel: pausedStr,
+				}, nil),
+			)
+			dm := newTestDriverManager(t, clientset, newFakeHost(), func(cfg *config) {
+				cfg.nodeLabelForGPUPodEviction = "example.com/gpu-workload"
+			})
+
+			require.NoError(t, dm.restoreLabels(tc.selector, tc.uncordon))
+
+			for name, expectedLabels := range tc.expectedNodes {
+				node, err := clientset.CoreV1().Nodes().Get(t.Context(), name, metav1.GetOptions{})
+				require.NoError(t, err)
+				require.Equal(t, expectedLabels, node.Labels, name)
+				require.Equal(t, tc.expectedUnschedulable[name], node.Spec.Unschedulable, name)
+			}
+		})
+	}
+}
+
+func TestRestoreLabelsCommand(t *testing.T) {
+	testCases := []struct {
+		description      string
+		nodeName         string
+		args             []string
+		expectedError    string
+		expectedRequests []string
+	}{
+		{
+			description:      "selector without a node name",
+			args:             []string{"--selector", "nvidia.com/gpu.present=true"},
+			expectedRequests: []string{"GET /api/v1/nodes"},
+		},
+		{
+			description:   "selector and node name",
+			nodeName:      testNodeName,
+			args:          []string{"--selector", "nvidia.com/gpu.present=true"},
+			expectedError: "the selector and node-name flags are mutually exclusive",
+		},
+		{
+			description:   "neither selector nor node name",
+			expectedError: "the node-name flag or the NODE_NAME environment variable must be set",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			t.Setenv("NODE_NAME", tc.nodeName)
+
+			requests, err := runTestApp(t, nil, append([]string{"restore_labels"}, tc.args...)...)
+			if tc.expectedError != "" {
+				require.ErrorContains(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.expectedRequests, requests)
+		})
+	}
+}
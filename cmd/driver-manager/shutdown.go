@@ -0,0 +1,65 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"time"
+)
+
+const defaultShutdownRollbackTimeout = 20 * time.Second
+
+// sleep waits for the given duration unless the driver manager is terminated first
+func (dm *DriverManager) sleep(d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-dm.ctx.Done():
+		return dm.ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// useRollbackContext switches the driver manager to a fresh context bounded by the shutdown
+// rollback timeout if its context is done, e.g. on SIGTERM or past the upgrade deadline, so
+// that changes made to the node can still be rolled back. The returned function switches back.
+func (dm *DriverManager) useRollbackContext() func() {
+	if dm.ctx.Err() == nil {
+		return func() {}
+	}
+
+	dm.log.Warnf("Rolling back the changes to node %s within %s: %v", dm.config.nodeName, dm.config.shutdownRollbackTimeout, dm.ctx.Err())
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(dm.ctx), dm.config.shutdownRollbackTimeout)
+	originalCtx, originalClient := dm.ctx, dm.kubeClient
+	dm.ctx, dm.kubeClient = ctx, dm.kubeClient.WithContext(ctx)
+	return func() {
+		cancel()
+		dm.ctx, dm.kubeClient = originalCtx, originalClient
+	}
+}
+
+// rollbackOnShutdown reschedules the paused GPU operator components and lifts the cordon or
+// taint driver-manager applied when it is terminated mid-upgrade without having cleaned up.
+func (dm *DriverManager) rollbackOnShutdown() {
+	if dm.ctx.Err() == nil || !dm.operandsPaused {
+		return
+	}
+	dm.cleanupOnFailure()
+}
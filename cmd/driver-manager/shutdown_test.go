@@ -0,0 +1,60 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestUninstallDriverRollsBackOnShutdown(t *testing.T) {
+	t.Setenv("DRIVER_CONFIG_DIGEST", testConfigDigest)
+
+	clientset := fake.NewClientset(newTestNode(defaultTestOperandLabels(), nil), newTestGPUPod("training"))
+	dm := newTestDriverManager(t, clientset, newFakeHost("nvidia"), func(cfg *config) {
+		// Block in the eviction notice period until driver-manager is terminated
+		cfg.gpuPodEvictionNoticePeriod = time.Hour
+		cfg.shutdownRollbackTimeout = 10 * time.Second
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	dm.ctx, dm.kubeClient = ctx, dm.kubeClient.WithContext(ctx)
+
+	go func() {
+		require.Eventually(t, func() bool {
+			node, err := clientset.CoreV1().Nodes().Get(context.Background(), testNodeName, metav1.GetOptions{})
+			return err == nil && node.Spec.Unschedulable
+		}, 5*time.Second, 10*time.Millisecond)
+		cancel()
+	}()
+
+	require.ErrorIs(t, dm.uninstallDriver(), context.Canceled)
+
+	node, err := clientset.CoreV1().Nodes().Get(context.Background(), testNodeName, metav1.GetOptions{})
+	require.NoError(t, err)
+	for label, value := range defaultTestOperandLabels() {
+		require.Equal(t, value, node.Labels[label], "label %s", label)
+	}
+	require.False(t, node.Spec.Unschedulable, "node is left cordoned")
+	require.NotContains(t, node.Annotations, cordonOwnerAnnotation)
+}
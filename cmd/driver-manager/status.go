@@ -0,0 +1,253 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/moby/sys/mountinfo"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"github.com/NVIDIA/k8s-driver-manager/internal/linuxutils"
+)
+
+const (
+	operandDeployLabelPrefix = nvidiaDomainPrefix + "/" + "gpu.deploy."
+
+	statusOutputTable = "table"
+	statusOutputJSON  = "json"
+)
+
+func newStatusCommand(cfg *config, components *componentState, log *logrus.Logger) *cli.Command {
+	var output string
+	return &cli.Command{
+		Name:   "status",
+		Usage:  "Report the NVIDIA driver state of the node without changing it. The liveness of the driver container process is only reported reliably in the host PID namespace",
+		Before: requireNodeName(cfg),
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "output",
+				Aliases:     []string{"o"},
+				Usage:       "Output format, one of: table, json",
+				Destination: &output,
+				Value:       statusOutputTable,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			dm, err := newDriverManager(c.Context, cfg, components, log)
+			if err != nil {
+				return fmt.Errorf("failed to create driver manager: %w", err)
+			}
+			status, err := dm.collectStatus()
+			if err != nil {
+				return fmt.Errorf("failed to collect node status: %w", err)
+			}
+			return printStatus(os.Stdout, status, output)
+		},
+	}
+}
+
+// nodeStatus is a read-only summary of the NVIDIA driver state of a node
+type nodeStatus struct {
+	Node string `json:"node"`
+
+	DriverLoaded  bool                `json:"driverLoaded"`
+	DriverVersion string              `json:"driverVersion,omitempty"`
+	Modules       []linuxutils.Module `json:"modules"`
+
+	DriverRootMounted     bool   `json:"driverRootMounted"`
+	DriverRootMountSource string `json:"driverRootMountSource,omitempty"`
+
+	// DriverPIDAlive reports whether the process of the driver container PID file exists
+	// under the proc root. The PID is recorded in the PID namespace of the driver container,
+	// so this is only meaningful when driver-manager shares it, e.g. the host PID namespace
+	// of the driver DaemonSet.
+	DriverPID      int  `json:"driverPID,omitempty"`
+	DriverPIDAlive bool `json:"driverPIDAlive"`
+
+	StoredConfigDigest  string `json:"storedConfigDigest,omitempty"`
+	DesiredConfigDigest string `json:"desiredConfigDigest,omitempty"`
+
+	OperandLabels map[string]string `json:"operandLabels"`
+	Cordoned      bool              `json:"cordoned"`
+	GPUPods       []string          `json:"gpuPods"`
+
+	// Errors lists the parts of the status which could not be determined
+	Errors []string `json:"errors,omitempty"`
+}
+
+// collectStatus gathers the driver state of the node. Host state which cannot be read is
+// reported in the Errors of the status rather than failing the whole command.
+func (dm *DriverManager) collectStatus() (*nodeStatus, error) {
+	status := &nodeStatus{
+		Node:                dm.config.nodeName,
+		DriverLoaded:        dm.isDriverLoaded(),
+		DriverVersion:       dm.loadedDriverVersion(),
+		DesiredConfigDigest: os.Getenv("DRIVER_CONFIG_DIGEST"),
+		OperandLabels:       make(map[string]string),
+	}
+
+	km := linuxutils.NewKernelModules(dm.log, linuxutils.WithProcRoot(dm.config.procRoot))
+	modules, err := km.Get("nvidia")
+	if err != nil {
+		status.Errors = append(status.Errors, fmt.Sprintf("kernel modules: %v", err))
+	}
+	status.Modules = modules
+
+	mount, err := dm.driverRootMount()
+	if err != nil {
+		status.Errors = append(status.Errors, fmt.Sprintf("driver root mount: %v", err))
+	}
+	if mount != nil {
+		status.DriverRootMounted = true
+		status.DriverRootMountSource = mount.Source
+	}
+
+	if data, err := dm.host.readFile(dm.driverPIDFile()); err == nil {
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			status.Errors = append(status.Errors, fmt.Sprintf("PID file %s: %v", dm.driverPIDFile(), err))
+		} else {
+			status.DriverPID = pid
+			status.DriverPIDAlive = dm.host.pathExists(dm.procPath(strconv.Itoa(pid)))
+		}
+	} else if !os.IsNotExist(err) {
+		status.Errors = append(status.Errors, fmt.Sprintf("PID file: %v", err))
+	}
+
+	if digest, err := dm.readStoredDigest(); err == nil {
+		status.StoredConfigDigest = digest
+	} else if !os.IsNotExist(err) {
+		status.Errors = append(status.Errors, fmt.Sprintf("driver config state file: %v", err))
+	}
+
+	node, err := dm.kubeClient.GetNode(dm.config.nodeName)
+	if err != nil {
+		return nil, err
+	}
+	for label, value := range node.Labels {
+		if dm.isOperandLabel(label) {
+			status.OperandLabels[label] = value
+		}
+	}
+	status.Cordoned = node.Spec.Unschedulable
+
+	gpuPods, err := dm.kubeClient.ListGPUPods(dm.config.nodeName)
+	if err != nil {
+		return nil, err
+	}
+	status.GPUPods = []string{}
+	for _, pod := range gpuPods {
+		status.GPUPods = append(status.GPUPods, pod.Namespace+"/"+pod.Name)
+	}
+
+	return status, nil
+}
+
+// driverRootMount returns the mount of the driver container rootfs, or nil if it is not mounted
+func (dm *DriverManager) driverRootMount() (*mountinfo.Info, error) {
+	data, err := dm.host.readFile(dm.procPath("self", "mountinfo"))
+	if err != nil {
+		return nil, err
+	}
+	mounts, err := mountinfo.GetMountsFromReader(bytes.NewReader(data), mountinfo.SingleEntryFilter(dm.driverRoot()))
+	if err != nil {
+		return nil, err
+	}
+	if len(mounts) == 0 {
+		return nil, nil
+	}
+	return mounts[0], nil
+}
+
+// printStatus writes the status in the given output format
+func printStatus(w io.Writer, status *nodeStatus, output string) error {
+	switch output {
+	case statusOutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(status)
+	case statusOutputTable:
+	default:
+		return fmt.Errorf("unsupported output format %q", output)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Node:\t%s\n", status.Node)
+	fmt.Fprintf(tw, "Driver loaded:\t%t\n", status.DriverLoaded)
+	fmt.Fprintf(tw, "Driver version:\t%s\n", valueOrNone(status.DriverVersion))
+	fmt.Fprintf(tw, "Driver rootfs mounted:\t%t\n", status.DriverRootMounted)
+	fmt.Fprintf(tw, "Driver rootfs mount source:\t%s\n", valueOrNone(status.DriverRootMountSource))
+	if status.DriverPID != 0 {
+		fmt.Fprintf(tw, "Driver PID:\t%d (alive: %t)\n", status.DriverPID, status.DriverPIDAlive)
+	} else {
+		fmt.Fprintf(tw, "Driver PID:\t<none>\n")
+	}
+	fmt.Fprintf(tw, "Stored config digest:\t%s\n", valueOrNone(status.StoredConfigDigest))
+	fmt.Fprintf(tw, "Desired config digest:\t%s\n", valueOrNone(status.DesiredConfigDigest))
+	fmt.Fprintf(tw, "Cordoned:\t%t\n", status.Cordoned)
+	fmt.Fprintln(tw)
+
+	fmt.Fprintf(tw, "MODULE\tSIZE\tREFCOUNT\tUSED BY\n")
+	for _, m := range status.Modules {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", m.Name, m.Size, m.RefCount, valueOrNone(strings.Join(m.UsedBy, ",")))
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintf(tw, "OPERAND LABEL\tVALUE\n")
+	labels := make([]string, 0, len(status.OperandLabels))
+	for label := range status.OperandLabels {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		fmt.Fprintf(tw, "%s\t%s\n", label, status.OperandLabels[label])
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintf(tw, "GPU POD\n")
+	for _, pod := range status.GPUPods {
+		fmt.Fprintf(tw, "%s\n", pod)
+	}
+
+	for _, e := range status.Errors {
+		fmt.Fprintf(tw, "\nWARNING: %s", e)
+	}
+	if len(status.Errors) > 0 {
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
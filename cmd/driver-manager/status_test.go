@@ -0,0 +1,223 @@
+//go:build !darwin && !windows
+
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/NVIDIA/k8s-driver-manager/internal/linuxutils"
+)
+
+func TestCollectAndPrintStatus(t *testing.T) {
+	const (
+		modules = `nvidia_uvm 1961984 0 - Live 0x0000000000000000 (PO)
+nvidia_modeset 1638400 1 nvidia_drm, Live 0x0000000000000000 (PO)
+nvidia 89210880 19 nvidia_uvm,nvidia_modeset, Live 0x0000000000000000 (PO)
+ext4 1019904 1 - Live 0x0000000000000000
+`
+		mountInfo = `22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw
+640 22 0:52 / /run/nvidia/driver rw,relatime shared:300 - overlay overlay rw
+`
+		driverVersion = "580.95.05"
+	)
+
+	cordoned := newTestNode(withLabels(defaultTestOperandLabels(), map[string]string{
+		"kubernetes.io/hostname": testNodeName,
+	}), nil)
+	cordoned.Spec.Unschedulable = true
+
+	testCases := []struct {
+		description string
+		objects     []runtime.Object
+		// procFiles are written below the proc root, which is read from disk for /proc/modules
+		procFiles map[string]string
+		// files are written to the fake host, relative paths below the proc root
+		files          map[string]string
+		loadedModules  []string
+		expectedStatus *nodeStatus
+		// expectedErrors are the prefixes of the expected status errors
+		expectedErrors []string
+		expectedTable  []string
+	}{
+		{
+			description:   "driver container running",
+			objects:       []runtime.Object{cordoned, newTestGPUPod("training")},
+			procFiles:     map[string]string{"modules": modules},
+			loadedModules: []string{"nvidia"},
+			files: map[string]string{
+				"self/mountinfo":                  mountInfo,
+				"4242/status":                     "",
+				"/sys/module/nvidia/version":      driverVersion + "\n",
+				"/run/nvidia/nvidia-driver.pid":   "4242\n",
+				"/run/nvidia/nvidia-driver.state": testConfigDigest + "\n",
+			},
+			expectedStatus: &nodeStatus{
+				Node:          testNodeName,
+				DriverLoaded:  true,
+				DriverVersion: driverVersion,
+				Modules: []linuxutils.Module{
+					{Name: "nvidia_uvm", Size: 1961984},
+					{Name: "nvidia_modeset", Size: 1638400, RefCount: 1, UsedBy: []string{"nvidia_drm"}},
+					{Name: "nvidia", Size: 89210880, RefCount: 19, UsedBy: []string{"nvidia_uvm", "nvidia_modeset"}},
+				},
+				DriverRootMounted:     true,
+				DriverRootMountSource: "overlay",
+				DriverPID:             4242,
+				DriverPIDAlive:        true,
+				StoredConfigDigest:    testConfigDigest,
+				DesiredConfigDigest:   testConfigDigest,
+				OperandLabels:         defaultTestOperandLabels(),
+				Cordoned:              true,
+				GPUPods:               []string{"default/training"},
+			},
+			expectedTable: []string{
+				"Node: " + testNodeName,
+				"Driver loaded: true",
+				"Driver version: " + driverVersion,
+				"Driver rootfs mounted: true",
+				"Driver rootfs mount source: overlay",
+				"Driver PID: 4242 (alive: true)",
+				"Stored config digest: " + testConfigDigest,
+				"Cordoned: true",
+				"MODULE SIZE REFCOUNT USED BY",
+				"nvidia_uvm 1961984 0 <none>",
+				"nvidia 89210880 19 nvidia_uvm,nvidia_modeset",
+				"OPERAND LABEL VALUE",
+				nvidiaDriverDeployLabel + " true",
+				"GPU POD",
+				"default/training",
+			},
+		},
+		{
+			description: "driver container exited",
+			objects:     []runtime.Object{newTestNode(defaultTestOperandLabels(), nil)},
+			procFiles:   map[string]string{"modules": "ext4 1019904 1 - Live 0x0000000000000000\n"},
+			files: map[string]string{
+				"self/mountinfo":                "22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw\n",
+				"/run/nvidia/nvidia-driver.pid": "4242\n",
+			},
+			expectedStatus: &nodeStatus{
+				Node:                testNodeName,
+				DriverPID:           4242,
+				DesiredConfigDigest: testConfigDigest,
+				OperandLabels:       defaultTestOperandLabels(),
+				GPUPods:             []string{},
+			},
+			expectedTable: []string{
+				"Driver loaded: false",
+				"Driver version: <none>",
+				"Driver rootfs mount source: <none>",
+				"Driver PID: 4242 (alive: false)",
+				"Stored config digest: <none>",
+			},
+		},
+		{
+			description: "unreadable host state is reported",
+			objects:     []runtime.Object{newTestNode(defaultTestOperandLabels(), nil)},
+			files: map[string]string{
+				"/run/nvidia/nvidia-driver.pid": "not-a-pid\n",
+			},
+			expectedStatus: &nodeStatus{
+				Node:                testNodeName,
+				DesiredConfigDigest: testConfigDigest,
+				OperandLabels:       defaultTestOperandLabels(),
+				GPUPods:             []string{},
+			},
+			expectedErrors: []string{
+				"kernel modules: ",
+				"driver root mount: ",
+				"PID file /run/nvidia/nvidia-driver.pid: ",
+			},
+			expectedTable: []string{
+				"Driver PID: <none>",
+				"WARNING: driver root mount: ",
+				"WARNING: PID file /run/nvidia/nvidia-driver.pid: ",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			t.Setenv("DRIVER_CONFIG_DIGEST", testConfigDigest)
+			procRoot := t.TempDir()
+			for name, content := range tc.procFiles {
+				require.NoError(t, os.WriteFile(filepath.Join(procRoot, name), []byte(content), 0644))
+			}
+			h := newFakeHost(tc.loadedModules...)
+			for path, content := range tc.files {
+				if !filepath.IsAbs(path) {
+					path = filepath.Join(procRoot, path)
+				}
+				h.files[path] = content
+			}
+			dm := newTestDriverManager(t, fake.NewClientset(tc.objects...), h, func(cfg *config) {
+				cfg.procRoot = procRoot
+			})
+
+			status, err := dm.collectStatus()
+			require.NoError(t, err)
+			require.Len(t, status.Errors, len(tc.expectedErrors), "errors: %v", status.Errors)
+			for i, prefix := range tc.expectedErrors {
+				require.True(t, strings.HasPrefix(status.Errors[i], prefix), "unexpected error: %s", status.Errors[i])
+			}
+
+			var table bytes.Buffer
+			require.NoError(t, printStatus(&table, status, statusOutputTable))
+			var lines []string
+			for _, line := range strings.Split(table.String(), "\n") {
+				lines = append(lines, strings.Join(strings.Fields(line), " "))
+			}
+			for _, expected := range tc.expectedTable {
+				found := false
+				for _, line := range lines {
+					if strings.HasPrefix(line, expected) {
+						found = true
+						break
+					}
+				}
+				require.True(t, found, "line %q not found in:\n%s", expected, table.String())
+			}
+
+			var output bytes.Buffer
+			require.NoError(t, printStatus(&output, status, statusOutputJSON))
+			decoded := &nodeStatus{}
+			require.NoError(t, json.Unmarshal(output.Bytes(), decoded))
+			require.Equal(t, status, decoded)
+
+			status.Errors = nil
+			require.Equal(t, tc.expectedStatus, status)
+		})
+	}
+}
+
+func TestPrintStatusUnsupportedOutput(t *testing.T) {
+	var output bytes.Buffer
+	err := printStatus(&output, &nodeStatus{Node: testNodeName}, "yaml")
+	require.ErrorContains(t, err, `unsupported output format "yaml"`)
+	require.Empty(t, output.String())
+}
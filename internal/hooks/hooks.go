@@ -0,0 +1,258 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"slices"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+)
+
+// Phase is a point of the driver upgrade at which hooks run
+type Phase string
+
+const (
+	// PhasePreOperandEviction runs before the GPU operator components are evicted from the node
+	PhasePreOperandEviction Phase = "pre-operand-eviction"
+	// PhasePreModuleUnload runs before the NVIDIA driver kernel modules are unloaded
+	PhasePreModuleUnload Phase = "pre-module-unload"
+	// PhasePostModuleUnload runs after the NVIDIA driver kernel modules have been unloaded
+	PhasePostModuleUnload Phase = "post-module-unload"
+	// PhasePreReschedule runs before the GPU operator components are rescheduled on the node
+	PhasePreReschedule Phase = "pre-reschedule"
+)
+
+// FailurePolicy defines how the failure of a hook affects the driver upgrade
+type FailurePolicy string
+
+const (
+	// FailOpen logs the failure of a hook and lets the driver upgrade proceed
+	FailOpen FailurePolicy = "fail-open"
+	// FailClosed aborts the driver upgrade when a hook fails
+	FailClosed FailurePolicy = "fail-closed"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Hook is a user-supplied action run at one or more phases of the driver upgrade. Exactly
+// one of Exec and HTTP must be set.
+type Hook struct {
+	Name   string  `json:"name"`
+	Phases []Phase `json:"phases"`
+
+	Exec *ExecHandler `json:"exec,omitempty"`
+	HTTP *HTTPHandler `json:"http,omitempty"`
+
+	// Timeout bounds the run of the hook, e.g. "30s"
+	Timeout       string        `json:"timeout,omitempty"`
+	FailurePolicy FailurePolicy `json:"failurePolicy,omitempty"`
+
+	timeout time.Duration
+}
+
+// ExecHandler runs an executable, e.g. one mounted into the container. The JSON payload is
+// passed on its standard input.
+type ExecHandler struct {
+	Command []string `json:"command"`
+}
+
+// HTTPHandler POSTs the JSON payload to a webhook. Any response status other than 2xx is a failure.
+type HTTPHandler struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Config is the hook configuration file
+type Config struct {
+	Hooks []Hook `json:"hooks"`
+}
+
+// Payload describes the driver upgrade to the hooks
+type Payload struct {
+	Node                 string    `json:"node"`
+	Phase                Phase     `json:"phase"`
+	CurrentDriverVersion string    `json:"currentDriverVersion,omitempty"`
+	DesiredDriverVersion string    `json:"desiredDriverVersion,omitempty"`
+	DriverConfigDigest   string    `json:"driverConfigDigest,omitempty"`
+	Timestamp            time.Time `json:"timestamp"`
+}
+
+// Runner runs the configured hooks
+type Runner struct {
+	log        *logrus.Logger
+	hooks      []Hook
+	httpClient *http.Client
+}
+
+// LoadConfig reads and validates a YAML or JSON hook configuration file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hook configuration %s: %w", path, err)
+	}
+
+	config := &Config{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse hook configuration %s: %w", path, err)
+	}
+
+	names := make(map[string]bool, len(config.Hooks))
+	for i := range config.Hooks {
+		if err := config.Hooks[i].validate(); err != nil {
+			return nil, fmt.Errorf("invalid hook %q: %w", config.Hooks[i].Name, err)
+		}
+		if names[config.Hooks[i].Name] {
+			return nil, fmt.Errorf("duplicate hook name %q", config.Hooks[i].Name)
+		}
+		names[config.Hooks[i].Name] = true
+	}
+	return config, nil
+}
+
+func (h *Hook) validate() error {
+	if h.Name == "" {
+		return fmt.Errorf("name must be set")
+	}
+	if (h.Exec == nil) == (h.HTTP == nil) {
+		return fmt.Errorf("exactly one of exec and http must be set")
+	}
+	if h.Exec != nil && len(h.Exec.Command) == 0 {
+		return fmt.Errorf("exec command must be set")
+	}
+	if h.HTTP != nil && h.HTTP.URL == "" {
+		return fmt.Errorf("http url must be set")
+	}
+	if len(h.Phases) == 0 {
+		return fmt.Errorf("at least one phase must be set")
+	}
+	for _, phase := range h.Phases {
+		switch phase {
+		case PhasePreOperandEviction, PhasePreModuleUnload, PhasePostModuleUnload, PhasePreReschedule:
+		default:
+			return fmt.Errorf("unknown phase %q", phase)
+		}
+	}
+	switch h.FailurePolicy {
+	case "":
+		h.FailurePolicy = FailClosed
+	case FailOpen, FailClosed:
+	default:
+		return fmt.Errorf("unknown failure policy %q", h.FailurePolicy)
+	}
+
+	h.timeout = defaultTimeout
+	if h.Timeout != "" {
+		timeout, err := time.ParseDuration(h.Timeout)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid timeout %q", h.Timeout)
+		}
+		h.timeout = timeout
+	}
+	return nil
+}
+
+// NewRunner returns a Runner for the hooks of a validated configuration
+func NewRunner(log *logrus.Logger, config *Config) *Runner {
+	return &Runner{
+		log:        log,
+		hooks:      config.Hooks,
+		httpClient: &http.Client{},
+	}
+}
+
+// Run runs the hooks registered for the phase of the payload in the order they are configured.
+// It returns an error as soon as a fail-closed hook fails.
+func (r *Runner) Run(ctx context.Context, payload Payload) error {
+	payload.Timestamp = time.Now().UTC()
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal hook payload: %w", err)
+	}
+
+	for _, hook := range r.hooks {
+		if !slices.Contains(hook.Phases, payload.Phase) {
+			continue
+		}
+
+		r.log.Infof("Running %s hook %q", payload.Phase, hook.Name)
+		if err := r.runHook(ctx, hook, body); err != nil {
+			if hook.FailurePolicy == FailOpen {
+				r.log.Warnf("Hook %q failed, proceeding as its failure policy is %s: %v", hook.Name, hook.FailurePolicy, err)
+				continue
+			}
+			return fmt.Errorf("%s hook %q failed: %w", payload.Phase, hook.Name, err)
+		}
+		r.log.Infof("Hook %q completed", hook.Name)
+	}
+	return nil
+}
+
+func (r *Runner) runHook(ctx context.Context, hook Hook, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, hook.timeout)
+	defer cancel()
+
+	if hook.Exec != nil {
+		return r.runExec(ctx, hook.Exec, body)
+	}
+	return r.runHTTP(ctx, hook.HTTP, body)
+}
+
+func (r *Runner) runExec(ctx context.Context, handler *ExecHandler, body []byte) error {
+	cmd := exec.CommandContext(ctx, handler.Command[0], handler.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(body)
+	output, err := cmd.CombinedOutput()
+	if len(output) > 0 {
+		r.log.Infof("Hook output: %s", bytes.TrimSpace(output))
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func (r *Runner) runHTTP(ctx context.Context, handler *HTTPHandler, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, handler.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range handler.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, bytes.TrimSpace(message))
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "hooks.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	testCases := []struct {
+		description string
+		content     string
+	}{
+		{"no handler", "hooks: [{name: a, phases: [pre-reschedule]}]"},
+		{"both handlers", "hooks: [{name: a, phases: [pre-reschedule], exec: {command: [true]}, http: {url: http://x}}]"},
+		{"no phase", "hooks: [{name: a, phases: [], exec: {command: [true]}}]"},
+		{"unknown phase", "hooks: [{name: a, phases: [whenever], exec: {command: [true]}}]"},
+		{"unknown failure policy", "hooks: [{name: a, phases: [pre-reschedule], exec: {command: [true]}, failurePolicy: maybe}]"},
+		{"invalid timeout", "hooks: [{name: a, phases: [pre-reschedule], exec: {command: [true]}, timeout: soon}]"},
+		{"duplicate name", "hooks: [{name: a, phases: [pre-reschedule], exec: {command: [true]}}, {name: a, phases: [pre-module-unload], exec: {command: [true]}}]"},
+		{"unknown field", "hooks: [{name: a, phase: pre-reschedule, exec: {command: [true]}}]"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, tc.content))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestRunnerRun(t *testing.T) {
+	var received []Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload Payload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		received = append(received, payload)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	config, err := LoadConfig(writeConfig(t, `
+hooks:
+- name: notify
+  phases: [pre-operand-eviction, pre-reschedule]
+  http:
+    url: `+server.URL+`/notify
+- name: telemetry
+  phases: [pre-module-unload]
+  http:
+    url: `+server.URL+`/fail
+  failurePolicy: fail-open
+- name: scheduler
+  phases: [pre-module-unload]
+  exec:
+    command: [/bin/sh, -c, "grep -q pre-module-unload"]
+- name: slurm
+  phases: [post-module-unload]
+  exec:
+    command: [/bin/sh, -c, "exit 1"]
+`))
+	require.NoError(t, err)
+	runner := NewRunner(logrus.New(), config)
+
+	require.NoError(t, runner.Run(context.Background(), Payload{Node: "node", Phase: PhasePreOperandEviction}))
+	require.Len(t, received, 1)
+	require.Equal(t, "node", received[0].Node)
+	require.Equal(t, PhasePreOperandEviction, received[0].Phase)
+
+	// The failing webhook is fail-open, the executable reads the payload from stdin
+	require.NoError(t, runner.Run(context.Background(), Payload{Node: "node", Phase: PhasePreModuleUnload}))
+	require.Len(t, received, 2)
+
+	// Hooks are fail-closed by default
+	require.Error(t, runner.Run(context.Background(), Payload{Node: "node", Phase: PhasePostModuleUnload}))
+}
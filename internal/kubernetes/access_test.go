@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestMissingPermissions(t *testing.T) {
+	clientset := fake.NewClientset()
+	clientset.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+		review.Status.Allowed = attributes.Resource == "nodes" || (attributes.Verb == "list" && attributes.Namespace == "gpu-operator")
+		return true, review, nil
+	})
+	c, err := NewClientFromClientset(context.Background(), clientset, logrus.New())
+	require.NoError(t, err)
+
+	missing, err := c.MissingPermissions([]Permission{
+		{Verb: "patch", Resource: "nodes"},
+		{Verb: "list", Resource: "pods", Namespace: "gpu-operator"},
+		{Verb: "list", Resource: "pods"},
+		{Verb: "create", Resource: "pods", Subresource: "eviction"},
+		{Verb: "get", Group: "coordination.k8s.io", Resource: "leases", Namespace: "gpu-operator"},
+	})
+	require.NoError(t, err)
+
+	var names []string
+	for _, p := range missing {
+		names = append(names, p.String())
+	}
+	require.Equal(t, []string{
+		"list pods",
+		"create pods/eviction",
+		"get leases.coordination.k8s.io in namespace gpu-operator",
+	}, names)
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	nvidiaVisibleDevicesEnvVar = "NVIDIA_VISIBLE_DEVICES"
+	nvidiaDeviceNodePrefix     = "/dev/nvidia"
+)
+
+// DefaultGPUResourceNamePatterns are the extended resource names that identify GPU pods
+// when no other patterns are configured
+var DefaultGPUResourceNamePatterns = []string{
+	nvidiaResourceNamePrefix + "*",
+	nvidiaMigResourcePrefix + "*",
+}
+
+// GPUPodClassifier decides whether a pod uses NVIDIA GPUs
+type GPUPodClassifier interface {
+	UsesGPU(pod corev1.Pod) (bool, error)
+}
+
+// GPUPodClassifierFunc adapts an ordinary function to the GPUPodClassifier interface
+type GPUPodClassifierFunc func(pod corev1.Pod) (bool, error)
+
+// UsesGPU calls f(pod)
+func (f GPUPodClassifierFunc) UsesGPU(pod corev1.Pod) (bool, error) {
+	return f(pod)
+}
+
+// NewResourceNameClassifier returns a classifier matching pods whose containers request or
+// limit an extended resource matching one of the given glob patterns, e.g. "nvidia.com/gpu*"
+func NewResourceNameClassifier(patterns []string) (GPUPodClassifier, error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid resource name pattern %q: %w", pattern, err)
+		}
+	}
+
+	matches := func(rl corev1.ResourceList) bool {
+		for resourceName := range rl {
+			for _, pattern := range patterns {
+				if ok, _ := path.Match(pattern, string(resourceName)); ok {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	return GPUPodClassifierFunc(func(pod corev1.Pod) (bool, error) {
+		for _, c := range pod.Spec.Containers {
+			if matches(c.Resources.Limits) || matches(c.Resources.Requests) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}), nil
+}
+
+// NewVisibleDevicesEnvClassifier returns a classifier matching pods with a container that sets
+// NVIDIA_VISIBLE_DEVICES to a device list, e.g. "all", bypassing the device plugin
+func NewVisibleDevicesEnvClassifier() GPUPodClassifier {
+	return GPUPodClassifierFunc(func(pod corev1.Pod) (bool, error) {
+		for _, c := range pod.Spec.Containers {
+			for _, env := range c.Env {
+				if env.Name != nvidiaVisibleDevicesEnvVar {
+					continue
+				}
+				switch strings.TrimSpace(env.Value) {
+				case "", "void", "none":
+				default:
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	})
+}
+
+// NewDeviceMountClassifier returns a classifier matching pods that mount NVIDIA device nodes
+// from the host, either directly or by mounting the whole of /dev into a privileged container
+func NewDeviceMountClassifier() GPUPodClassifier {
+	return GPUPodClassifierFunc(func(pod corev1.Pod) (bool, error) {
+		devVolumes := make(map[string]bool)
+		for _, volume := range pod.Spec.Volumes {
+			if volume.HostPath == nil {
+				continue
+			}
+			hostPath := path.Clean(volume.HostPath.Path)
+			switch {
+			case strings.HasPrefix(hostPath, nvidiaDeviceNodePrefix):
+				devVolumes[volume.Name] = false
+			case hostPath == "/dev":
+				devVolumes[volume.Name] = true
+			}
+		}
+		if len(devVolumes) == 0 {
+			return false, nil
+		}
+
+		for _, c := range pod.Spec.Containers {
+			privileged := c.SecurityContext != nil && c.SecurityContext.Privileged != nil && *c.SecurityContext.Privileged
+			for _, mount := range c.VolumeMounts {
+				wholeDev, ok := devVolumes[mount.Name]
+				if !ok {
+					continue
+				}
+				if !wholeDev || privileged {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	})
+}
+
+// resettableClassifier is implemented by the GPU pod classifiers which cache host state. The
+// client resets them at the start of each classification pass, so that each pass sees the
+// current state of the host.
+type resettableClassifier interface {
+	Reset()
+}
+
+// podUIDClassifier matches the pods whose UIDs are returned by a scan
+type podUIDClassifier struct {
+	scan func() ([]string, error)
+
+	mu   sync.Mutex
+	uids map[string]bool
+}
+
+// NewPodUIDClassifier returns a classifier matching the pods whose UIDs are returned by scan,
+// e.g. the pods owning host processes which hold NVIDIA device nodes open. The scan runs the
+// first time the classifier is used after it is created or reset, and again the next time if
+// it failed.
+func NewPodUIDClassifier(scan func() ([]string, error)) GPUPodClassifier {
+	return &podUIDClassifier{scan: scan}
+}
+
+// UsesGPU reports whether the UID of the pod was returned by the scan
+func (c *podUIDClassifier) UsesGPU(pod corev1.Pod) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.uids == nil {
+		found, err := c.scan()
+		if err != nil {
+			return false, err
+		}
+		c.uids = make(map[string]bool, len(found))
+		for _, uid := range found {
+			c.uids[uid] = true
+		}
+	}
+	return c.uids[string(pod.UID)], nil
+}
+
+// Reset drops the result of the last scan, so that the next classification scans again
+func (c *podUIDClassifier) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.uids = nil
+}
+
+// newDRAClaimClassifier returns a classifier matching pods which hold a ResourceClaim
+// allocated by the NVIDIA GPU DRA driver
+func newDRAClaimClassifier(c *Client) GPUPodClassifier {
+	return GPUPodClassifierFunc(c.podHasGPUResourceClaim)
+}
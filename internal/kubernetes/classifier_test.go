@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGPUPodClassifiers(t *testing.T) {
+	privileged := true
+	withResource := func(name string) corev1.PodSpec {
+		return corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceName(name): resource.MustParse("1")},
+				},
+			}},
+		}
+	}
+	withEnv := func(value string) corev1.PodSpec {
+		return corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Env: []corev1.EnvVar{{Name: nvidiaVisibleDevicesEnvVar, Value: value}},
+			}},
+		}
+	}
+	withHostPath := func(hostPath string, privileged *bool) corev1.PodSpec {
+		return corev1.PodSpec{
+			Volumes: []corev1.Volume{{
+				Name: "dev",
+				VolumeSource: corev1.VolumeSource{
+					HostPath: &corev1.HostPathVolumeSource{Path: hostPath},
+				},
+			}},
+			Containers: []corev1.Container{{
+				VolumeMounts:    []corev1.VolumeMount{{Name: "dev", MountPath: hostPath}},
+				SecurityContext: &corev1.SecurityContext{Privileged: privileged},
+			}},
+		}
+	}
+
+	defaultResources, err := NewResourceNameClassifier(DefaultGPUResourceNamePatterns)
+	require.NoError(t, err)
+	customResources, err := NewResourceNameClassifier([]string{"nvidia.com/pgpu", "nvidia.com/*-shared"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		description     string
+		classifier      GPUPodClassifier
+		spec            corev1.PodSpec
+		expectedUsesGPU bool
+	}{
+		{"default resource names, gpu", defaultResources, withResource("nvidia.com/gpu"), true},
+		{"default resource names, mig", defaultResources, withResource("nvidia.com/mig-1g.10gb"), true},
+		{"default resource names, pgpu", defaultResources, withResource("nvidia.com/pgpu"), false},
+		{"default resource names, cpu", defaultResources, withResource("cpu"), false},
+		{"custom resource names, pgpu", customResources, withResource("nvidia.com/pgpu"), true},
+		{"custom resource names, renamed time-sliced", customResources, withResource("nvidia.com/a100-shared"), true},
+		{"visible devices env, all", NewVisibleDevicesEnvClassifier(), withEnv("all"), true},
+		{"visible devices env, void", NewVisibleDevicesEnvClassifier(), withEnv("void"), false},
+		{"visible devices env, none", NewVisibleDevicesEnvClassifier(), withEnv("none"), false},
+		{"device mount, nvidia device node", NewDeviceMountClassifier(), withHostPath("/dev/nvidia0", nil), true},
+		{"device mount, /dev privileged", NewDeviceMountClassifier(), withHostPath("/dev", &privileged), true},
+		{"device mount, /dev unprivileged", NewDeviceMountClassifier(), withHostPath("/dev", nil), false},
+		{"device mount, other path", NewDeviceMountClassifier(), withHostPath("/var/log", &privileged), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			usesGPU, err := tc.classifier.UsesGPU(corev1.Pod{Spec: tc.spec})
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedUsesGPU, usesGPU)
+		})
+	}
+}
+
+func TestNewResourceNameClassifierInvalidPattern(t *testing.T) {
+	_, err := NewResourceNameClassifier([]string{"nvidia.com/[gpu"})
+	require.Error(t, err)
+}
+
+func TestPodUIDClassifier(t *testing.T) {
+	scans := 0
+	scanErr := errors.New("permission denied")
+	classifier := NewPodUIDClassifier(func() ([]string, error) {
+		scans++
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		return []string{"gpu-pod-uid"}, nil
+	})
+
+	// A failed scan is retried the next time the classifier is used
+	_, err := classifier.UsesGPU(corev1.Pod{})
+	require.ErrorIs(t, err, scanErr)
+	scanErr = nil
+
+	gpuPod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: "gpu-pod-uid"}}
+	usesGPU, err := classifier.UsesGPU(gpuPod)
+	require.NoError(t, err)
+	require.True(t, usesGPU)
+
+	usesGPU, err = classifier.UsesGPU(corev1.Pod{ObjectMeta: metav1.ObjectMeta{UID: "cpu-pod-uid"}})
+	require.NoError(t, err)
+	require.False(t, usesGPU)
+	require.Equal(t, 2, scans)
+
+	// The scan is cached until the classifier is reset
+	classifier.(resettableClassifier).Reset()
+	usesGPU, err = classifier.UsesGPU(gpuPod)
+	require.NoError(t, err)
+	require.True(t, usesGPU)
+	require.Equal(t, 3, scans)
+}
@@ -33,9 +33,10 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/util/retry"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/drain"
 )
@@ -46,7 +47,18 @@ const (
 	nvidiaMigResourcePrefix  = nvidiaDomainPrefix + "/" + "mig-"
 	nvidiaDRADriverName      = "gpu." + nvidiaDomainPrefix
 
+	// GPUPodEvictionDeadlineAnnotation is set on GPU pods ahead of their eviction and holds the
+	// RFC 3339 time after which the pod will be evicted, so workload controllers can checkpoint.
+	GPUPodEvictionDeadlineAnnotation = nvidiaDomainPrefix + "/" + "gpu-driver-upgrade-eviction-deadline"
+	// GPUPodEvictionPendingCondition is the pod condition type optionally added to GPU pods
+	// ahead of their eviction.
+	GPUPodEvictionPendingCondition corev1.PodConditionType = nvidiaDomainPrefix + "/" + "gpu-driver-upgrade-eviction-pending"
+
 	kubeClientPollInterval = 5 * time.Second
+
+	// FieldManager is the field manager of the node labels and annotations applied by
+	// driver-manager
+	FieldManager = "k8s-driver-manager"
 )
 
 // Client represents a Kubernetes client wrapper use to perform all the Kubernetes operations required by k8s-driver-manager
@@ -54,7 +66,66 @@ type Client struct {
 	ctx context.Context
 	log *logrus.Logger
 
-	clientset *kubernetes.Clientset
+	clientset     kubernetes.Interface
+	dynamicClient dynamic.Interface
+	pollInterval  time.Duration
+	retryBackoff  wait.Backoff
+
+	forceFieldOwnership bool
+
+	gpuResourceNamePatterns []string
+	extraGPUPodClassifiers  []GPUPodClassifier
+	gpuPodClassifiers       []GPUPodClassifier
+}
+
+// Option defines a function for passing options to the NewClient() call
+type Option func(*Client)
+
+// WithPollInterval sets the interval at which the client polls the API server while waiting
+func WithPollInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		c.pollInterval = interval
+	}
+}
+
+// WithDynamicClient sets the client used for the custom resources driver-manager manages, e.g.
+// a fake dynamic client in tests
+func WithDynamicClient(dynamicClient dynamic.Interface) Option {
+	return func(c *Client) {
+		c.dynamicClient = dynamicClient
+	}
+}
+
+// WithRetryBackoff sets the backoff between the attempts of a request to the API server
+// failing with a retryable error, replacing DefaultRetryBackoff
+func WithRetryBackoff(backoff wait.Backoff) Option {
+	return func(c *Client) {
+		c.retryBackoff = backoff
+	}
+}
+
+// WithForceFieldOwnership sets whether node labels and annotations owned by another field
+// manager are overwritten when they conflict with those applied by driver-manager
+func WithForceFieldOwnership(force bool) Option {
+	return func(c *Client) {
+		c.forceFieldOwnership = force
+	}
+}
+
+// WithGPUResourceNamePatterns sets the glob patterns of the extended resource names identifying
+// GPU pods, replacing DefaultGPUResourceNamePatterns
+func WithGPUResourceNamePatterns(patterns []string) Option {
+	return func(c *Client) {
+		c.gpuResourceNamePatterns = patterns
+	}
+}
+
+// WithGPUPodClassifiers adds classifiers identifying GPU pods in addition to the resource name
+// and DRA ResourceClaim checks which are always performed
+func WithGPUPodClassifiers(classifiers ...GPUPodClassifier) Option {
+	return func(c *Client) {
+		c.extraGPUPodClassifiers = append(c.extraGPUPodClassifiers, classifiers...)
+	}
 }
 
 // DrainOptions represents the option parameters that can passed to the drain.Helper struct
@@ -63,10 +134,16 @@ type DrainOptions struct {
 	DeleteEmptyDirData bool
 	Timeout            time.Duration
 	PodSelector        string
+	// GracePeriodSeconds is the termination grace period given to the GPU pods deleted or
+	// evicted by DeleteOrEvictPods. A negative value uses the grace period of each pod.
+	GracePeriodSeconds int
+	// EvictionPolicy selects and orders the GPU pods evicted by DeleteOrEvictPods. DrainNode
+	// leaves the GPU pods it does not allow to be evicted running.
+	EvictionPolicy GPUPodEvictionPolicy
 }
 
 // NewClient instantiates a new Kubernetes.Client
-func NewClient(ctx context.Context, kubeconfig string, log *logrus.Logger) (*Client, error) {
+func NewClient(ctx context.Context, kubeconfig string, log *logrus.Logger, opts ...Option) (*Client, error) {
 	// Load kubeconfig
 	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
 	if err != nil {
@@ -79,18 +156,79 @@ func NewClient(ctx context.Context, kubeconfig string, log *logrus.Logger) (*Cli
 		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
 	}
 
-	return &Client{
-		ctx:       ctx,
-		log:       log,
-		clientset: k8sClientSet,
-	}, nil
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic kubernetes client: %w", err)
+	}
+
+	return NewClientFromClientset(ctx, k8sClientSet, log, append([]Option{WithDynamicClient(dynamicClient)}, opts...)...)
+}
+
+// NewClientFromClientset instantiates a new Kubernetes.Client from an existing clientset,
+// e.g. a fake clientset in tests
+func NewClientFromClientset(ctx context.Context, clientset kubernetes.Interface, log *logrus.Logger, opts ...Option) (*Client, error) {
+	c := &Client{
+		ctx:          ctx,
+		log:          log,
+		clientset:    clientset,
+		pollInterval: kubeClientPollInterval,
+		retryBackoff: DefaultRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if len(c.gpuResourceNamePatterns) == 0 {
+		c.gpuResourceNamePatterns = DefaultGPUResourceNamePatterns
+	}
+
+	resourceNameClassifier, err := NewResourceNameClassifier(c.gpuResourceNamePatterns)
+	if err != nil {
+		return nil, err
+	}
+	c.gpuPodClassifiers = append([]GPUPodClassifier{resourceNameClassifier, newDRAClaimClassifier(c)}, c.extraGPUPodClassifiers...)
+
+	return c, nil
+}
+
+// WithContext returns a copy of the client which performs its operations with the given
+// context, e.g. to roll back changes after the original context has been cancelled
+func (c *Client) WithContext(ctx context.Context) *Client {
+	clone := *c
+	clone.ctx = ctx
+	return &clone
+}
+
+// GetNode returns a Node given a Node name
+func (c *Client) GetNode(nodeName string) (*corev1.Node, error) {
+	var node *corev1.Node
+	err := c.retry("get node "+nodeName, func(ctx context.Context) (err error) {
+		node, err = c.clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+	return node, nil
+}
+
+// ListNodes returns the Nodes matching a label selector
+func (c *Client) ListNodes(selector string) ([]corev1.Node, error) {
+	var nodes *corev1.NodeList
+	err := c.retry(fmt.Sprintf("list nodes matching %q", selector), func(ctx context.Context) (err error) {
+		nodes, err = c.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: selector})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes matching %q: %w", selector, err)
+	}
+	return nodes.Items, nil
 }
 
 // GetNodeLabelValue returns the label value given a label key and node
 func (c *Client) GetNodeLabelValue(nodeName, label string) (string, error) {
-	node, err := c.clientset.CoreV1().Nodes().Get(c.ctx, nodeName, metav1.GetOptions{})
+	node, err := c.GetNode(nodeName)
 	if err != nil {
-		return "", fmt.Errorf("failed to get node %s: %w", nodeName, err)
+		return "", err
 	}
 
 	if node.Labels == nil {
@@ -101,42 +239,121 @@ func (c *Client) GetNodeLabelValue(nodeName, label string) (string, error) {
 }
 
 // UpdateNodeLabels updates the labels on a Node given a Node name and a string map of label key-value pairs
-// This method uses a strategic merge patch to avoid conflicts with concurrent updates
+// The labels are applied server-side with the FieldManager of driver-manager.
 func (c *Client) UpdateNodeLabels(nodeName string, nodeLabels map[string]string) error {
-	patch := map[string]interface{}{
-		"metadata": map[string]interface{}{
-			"labels": nodeLabels,
-		},
+	return c.UpdateNodeMetadata(nodeName, nodeLabels, nil)
+}
+
+// UpdateNodeAnnotations updates the annotations on a Node given a Node name. Annotations with
+// a nil value are removed.
+func (c *Client) UpdateNodeAnnotations(nodeName string, annotations map[string]*string) error {
+	return c.UpdateNodeMetadata(nodeName, nil, annotations)
+}
+
+// UpdateNodeMetadata updates the labels and annotations on a Node in a single server-side
+// apply given a Node name. Annotations with a nil value are removed.
+//
+// The applied configuration is the one previously applied by FieldManager, so that the labels
+// and annotations owned by driver-manager are visible in the managedFields of the Node. Values
+// conflicting with those owned by another field manager, e.g. the GPU Operator, are logged and
+// only overwritten if the client forces field ownership.
+func (c *Client) UpdateNodeMetadata(nodeName string, nodeLabels map[string]string, annotations map[string]*string) error {
+	err := c.retry("update metadata of node "+nodeName, func(ctx context.Context) error {
+		_, err := c.applyNodeMetadata(ctx, nodeName, "", nodeLabels, annotations)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update metadata of node %s: %w", nodeName, err)
 	}
+	return nil
+}
 
-	patchBytes, err := json.Marshal(patch)
+// applyNodeMetadata applies the labels and annotations to a Node and returns the updated Node.
+// If resourceVersion is set, the update is only performed on the Node at this resourceVersion.
+func (c *Client) applyNodeMetadata(ctx context.Context, nodeName, resourceVersion string, nodeLabels map[string]string, annotations map[string]*string) (*corev1.Node, error) {
+	node, err := c.clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
 	if err != nil {
-		return fmt.Errorf("failed to marshal patch: %w", err)
+		return nil, err
+	}
+	if resourceVersion != "" && node.ResourceVersion != resourceVersion {
+		return nil, fmt.Errorf("%w: resourceVersion %s is not %s", ErrNodeModified, node.ResourceVersion, resourceVersion)
+	}
+	applyConfig, err := corev1ac.ExtractNode(node, FieldManager)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract the configuration applied to node %s: %w", nodeName, err)
+	}
+	if resourceVersion != "" {
+		applyConfig.WithResourceVersion(resourceVersion)
 	}
 
-	backoff := wait.Backoff{
-		Duration: time.Second,
-		Factor:   2.0,
-		Jitter:   0.2,
-		Steps:    7,
+	applyConfig.WithLabels(nodeLabels)
+	// Annotations written before server-side apply was used are not owned by FieldManager, so
+	// dropping them from the applied configuration does not remove them
+	unowned := make(map[string]interface{})
+	for key, value := range annotations {
+		if value != nil {
+			applyConfig.WithAnnotations(map[string]string{key: *value})
+			continue
+		}
+		if _, owned := applyConfig.Annotations[key]; !owned {
+			if _, ok := node.Annotations[key]; ok {
+				unowned[key] = nil
+			}
+		}
+		delete(applyConfig.Annotations, key)
 	}
 
-	return retry.OnError(backoff, func(err error) bool {
-		return true
-	}, func() error {
-		_, err := c.clientset.CoreV1().Nodes().Patch(c.ctx, nodeName, types.StrategicMergePatchType, patchBytes, metav1.PatchOptions{})
-		if err != nil {
-			c.log.Warnf("Failed to update labels on node %s, retrying: %v", nodeName, err)
+	opts := metav1.ApplyOptions{FieldManager: FieldManager}
+	updated, err := c.clientset.CoreV1().Nodes().Apply(ctx, applyConfig, opts)
+	if apierrors.IsConflict(err) && apierrors.HasStatusCause(err, metav1.CauseTypeFieldManagerConflict) {
+		c.log.Warnf("Labels or annotations of node %s are managed by another field manager: %v", nodeName, err)
+		if !c.forceFieldOwnership {
+			return nil, fmt.Errorf("%w: %w", errFieldOwnershipConflict, err)
 		}
-		return err
+		c.log.Warnf("Taking ownership of the conflicting labels and annotations of node %s", nodeName)
+		opts.Force = true
+		updated, err = c.clientset.CoreV1().Nodes().Apply(ctx, applyConfig, opts)
+	}
+	if err != nil {
+		return nil, nodeUpdateError(err, resourceVersion)
+	}
+
+	if len(unowned) == 0 {
+		return updated, nil
+	}
+	metadata := map[string]interface{}{
+		"annotations": unowned,
+	}
+	if resourceVersion != "" {
+		metadata["resourceVersion"] = updated.ResourceVersion
+	}
+	patchBytes, err := json.Marshal(map[string]interface{}{
+		"metadata": metadata,
 	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal patch: %w", err)
+	}
+	updated, err = c.clientset.CoreV1().Nodes().Patch(ctx, nodeName, types.MergePatchType, patchBytes, metav1.PatchOptions{FieldManager: FieldManager})
+	if err != nil {
+		return nil, nodeUpdateError(err, resourceVersion)
+	}
+	return updated, nil
+}
+
+// nodeUpdateError marks a conflicting update of a Node made with a resourceVersion
+// precondition as failed because the Node has been modified
+func nodeUpdateError(err error, resourceVersion string) error {
+	if resourceVersion != "" && apierrors.IsConflict(err) {
+		return fmt.Errorf("%w: %w", ErrNodeModified, err)
+	}
+	return err
 }
 
 // GetNodeAnnotationValue returns the annotation value given a node name and annotation key
 func (c *Client) GetNodeAnnotationValue(nodeName, annotation string) (string, error) {
-	node, err := c.clientset.CoreV1().Nodes().Get(c.ctx, nodeName, metav1.GetOptions{})
+	node, err := c.GetNode(nodeName)
 	if err != nil {
-		return "", fmt.Errorf("failed to get node %s: %w", nodeName, err)
+		return "", err
 	}
 	if node.Annotations == nil {
 		return "", nil
@@ -144,30 +361,172 @@ func (c *Client) GetNodeAnnotationValue(nodeName, annotation string) (string, er
 	return node.Annotations[annotation], nil
 }
 
+// GetNodeConditionStatus returns the status of a condition given a node name and condition
+// type, or an empty status if the node has no such condition
+func (c *Client) GetNodeConditionStatus(nodeName string, conditionType corev1.NodeConditionType) (corev1.ConditionStatus, error) {
+	node, err := c.GetNode(nodeName)
+	if err != nil {
+		return "", err
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.Status, nil
+		}
+	}
+	return "", nil
+}
+
+// SetNodeCondition adds or updates a condition in the status of a Node given a Node name
+func (c *Client) SetNodeCondition(nodeName string, condition corev1.NodeCondition) error {
+	now := metav1.Now()
+	condition.LastHeartbeatTime = now
+	condition.LastTransitionTime = now
+
+	patch := map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []corev1.NodeCondition{condition},
+		},
+	}
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal patch: %w", err)
+	}
+
+	err = c.retry(fmt.Sprintf("set condition %s on node %s", condition.Type, nodeName), func(ctx context.Context) error {
+		_, err := c.clientset.CoreV1().Nodes().Patch(ctx, nodeName, types.StrategicMergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set condition %s on node %s: %w", condition.Type, nodeName, err)
+	}
+	return nil
+}
+
+// GetConfigMapData returns the data of a ConfigMap given its namespace and name
+func (c *Client) GetConfigMapData(namespace, name string) (map[string]string, error) {
+	var configMap *corev1.ConfigMap
+	err := c.retry(fmt.Sprintf("get configmap %s/%s", namespace, name), func(ctx context.Context) (err error) {
+		configMap, err = c.clientset.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configmap %s/%s: %w", namespace, name, err)
+	}
+	return configMap.Data, nil
+}
+
 // CordonNode cordons a Node given a Node name marking it as Unschedulable
 func (c *Client) CordonNode(nodeName string) error {
 	c.log.Infof("Cordoning node %s", nodeName)
 
-	node, err := c.clientset.CoreV1().Nodes().Get(c.ctx, nodeName, metav1.GetOptions{})
+	err := c.retry("cordon node "+nodeName, func(ctx context.Context) error {
+		node, err := c.clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		drainHelper := &drain.Helper{Ctx: ctx, Client: c.clientset}
+		return drain.RunCordonOrUncordon(drainHelper, node, true)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get node %s: %w", nodeName, err)
+		return fmt.Errorf("failed to cordon node %s: %w", nodeName, err)
 	}
-
-	drainHelper := &drain.Helper{Ctx: c.ctx, Client: c.clientset}
-	return drain.RunCordonOrUncordon(drainHelper, node, true)
+	return nil
 }
 
 // UncordonNode uncordons a Node given a Node name marking it as Schedulable
 func (c *Client) UncordonNode(nodeName string) error {
 	c.log.Infof("Uncordoning node %s", nodeName)
 
-	node, err := c.clientset.CoreV1().Nodes().Get(c.ctx, nodeName, metav1.GetOptions{})
+	err := c.retry("uncordon node "+nodeName, func(ctx context.Context) error {
+		node, err := c.clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		drainHelper := &drain.Helper{Ctx: ctx, Client: c.clientset}
+		return drain.RunCordonOrUncordon(drainHelper, node, false)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to uncordon node %s: %w", nodeName, err)
+	}
+	return nil
+}
+
+// AddNodeTaint adds a taint to a Node given a Node name, replacing any taint with the same
+// key and effect
+func (c *Client) AddNodeTaint(nodeName string, taint corev1.Taint) error {
+	c.log.Infof("Tainting node %s with %s", nodeName, taint.ToString())
+
+	return c.updateNodeTaints(nodeName, func(taints []corev1.Taint) []corev1.Taint {
+		var updated []corev1.Taint
+		for _, t := range taints {
+			if !t.MatchTaint(&taint) {
+				updated = append(updated, t)
+			}
+		}
+		if taint.TimeAdded == nil && taint.Effect == corev1.TaintEffectNoExecute {
+			now := metav1.Now()
+			taint.TimeAdded = &now
+		}
+		return append(updated, taint)
+	})
+}
+
+// RemoveNodeTaint removes all taints with the given key from a Node given a Node name
+func (c *Client) RemoveNodeTaint(nodeName, key string) error {
+	return c.updateNodeTaints(nodeName, func(taints []corev1.Taint) []corev1.Taint {
+		var updated []corev1.Taint
+		for _, t := range taints {
+			if t.Key != key {
+				updated = append(updated, t)
+			}
+		}
+		return updated
+	})
+}
+
+// updateNodeTaints updates the taints of a Node, retrying on conflicting updates
+func (c *Client) updateNodeTaints(nodeName string, update func([]corev1.Taint) []corev1.Taint) error {
+	err := c.retry("update taints of node "+nodeName, func(ctx context.Context) error {
+		node, err := c.clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		taints := update(node.Spec.Taints)
+		if len(taints) == len(node.Spec.Taints) && equalTaints(taints, node.Spec.Taints) {
+			return nil
+		}
+		node.Spec.Taints = taints
+		_, err = c.clientset.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{})
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get node %s: %w", nodeName, err)
+		return fmt.Errorf("failed to update taints of node %s: %w", nodeName, err)
+	}
+	return nil
+}
+
+func equalTaints(a, b []corev1.Taint) bool {
+	for i := range a {
+		if !a[i].MatchTaint(&b[i]) || a[i].Value != b[i].Value {
+			return false
+		}
 	}
+	return true
+}
 
-	drainHelper := &drain.Helper{Ctx: c.ctx, Client: c.clientset}
-	return drain.RunCordonOrUncordon(drainHelper, node, false)
+// ListNodePods returns the pods in a namespace which are bound to a Node given a Node name
+func (c *Client) ListNodePods(namespace, nodeName string) ([]corev1.Pod, error) {
+	var podList *corev1.PodList
+	err := c.retry("list pods on node "+nodeName, func(ctx context.Context) (err error) {
+		podList, err = c.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods on node %s: %w", nodeName, err)
+	}
+	return podList.Items, nil
 }
 
 // WaitForPodTermination will wait for the termination of pods matching labels from the selectorMap on the node with the specified namespace.
@@ -175,11 +534,15 @@ func (c *Client) UncordonNode(nodeName string) error {
 func (c *Client) WaitForPodTermination(selectorMap map[string]string, namespace, nodeName string, timeout time.Duration) error {
 	selector := labels.SelectorFromSet(selectorMap)
 
-	return wait.PollUntilContextTimeout(c.ctx, kubeClientPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
-		pods, err := c.clientset.CoreV1().Pods(namespace).List(c.ctx, metav1.ListOptions{
+	return wait.PollUntilContextTimeout(c.ctx, c.pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		pods, err := c.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
 			LabelSelector: selector.String(),
 			FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
 		})
+		if isRetryable(err) {
+			c.log.Warnf("Failed to list pods on node %s, retrying in %s: %v", nodeName, c.pollInterval, err)
+			return false, nil
+		}
 		if err != nil {
 			return false, err
 		}
@@ -192,10 +555,14 @@ func (c *Client) WaitForPodTermination(selectorMap map[string]string, namespace,
 // WaitForPodsWithNodeSelector waits for all daemon set pods on the given node which has the specified key in
 // its nodeSelector to terminate.
 func (c *Client) WaitForPodsWithNodeSelector(nodeName, nodeSelectorKey string, timeout time.Duration) error {
-	return wait.PollUntilContextTimeout(c.ctx, kubeClientPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+	return wait.PollUntilContextTimeout(c.ctx, c.pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
 		podList, err := c.clientset.CoreV1().Pods(corev1.NamespaceAll).List(ctx, metav1.ListOptions{
 			FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
 		})
+		if isRetryable(err) {
+			c.log.Warnf("Failed to list pods on node %s, retrying in %s: %v", nodeName, c.pollInterval, err)
+			return false, nil
+		}
 		if err != nil {
 			return false, fmt.Errorf("failed to list pods on node %s: %w", nodeName, err)
 		}
@@ -223,15 +590,13 @@ func (c *Client) WaitForPodsWithNodeSelector(nodeName, nodeSelectorKey string, t
 // kubelet still needs the DRA kubelet-plugin to unprepare. Pods in a terminal phase are
 // excluded, since the kubelet has unprepared their claims by then.
 func (c *Client) GetGPUResourceClaimHolders(nodeName string) ([]string, error) {
-	podList, err := c.clientset.CoreV1().Pods(corev1.NamespaceAll).List(c.ctx, metav1.ListOptions{
-		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
-	})
+	pods, err := c.ListNodePods(corev1.NamespaceAll, nodeName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list pods on node %s: %w", nodeName, err)
+		return nil, err
 	}
 
 	var holders []string
-	for _, pod := range podList.Items {
+	for _, pod := range pods {
 		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
 			continue
 		}
@@ -247,9 +612,69 @@ func (c *Client) GetGPUResourceClaimHolders(nodeName string) ([]string, error) {
 	return holders, nil
 }
 
-// DrainNode drains a Node given a Node name and a set of drain option parameters
+// ListGPUPods returns the pods on the node which are not in a terminal phase and use NVIDIA GPUs
+func (c *Client) ListGPUPods(nodeName string) ([]corev1.Pod, error) {
+	c.resetGPUPodClassifiers()
+
+	pods, err := c.ListNodePods(corev1.NamespaceAll, nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	var gpuPods []corev1.Pod
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		usesGPU, err := c.podUsesGPU(pod)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check GPU usage for pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		if usesGPU {
+			gpuPods = append(gpuPods, pod)
+		}
+	}
+	return gpuPods, nil
+}
+
+// ListGPUPodsKeptByEvictionPolicy returns the namespaced names of the GPU pods on the node
+// which the eviction policy does not allow to be evicted
+func (c *Client) ListGPUPodsKeptByEvictionPolicy(nodeName string, policy GPUPodEvictionPolicy) ([]string, error) {
+	gpuPods, err := c.ListGPUPods(nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	var kept []string
+	for _, pod := range gpuPods {
+		if ok, _ := policy.evictable(pod); !ok {
+			kept = append(kept, pod.Namespace+"/"+pod.Name)
+		}
+	}
+	return kept, nil
+}
+
+// DrainNode drains a Node given a Node name and a set of drain option parameters. GPU pods
+// kept by the eviction policy of the options are left running.
 func (c *Client) DrainNode(nodeName string, drainOpts DrainOptions) error {
 	c.log.Infof("Draining node %s", nodeName)
+	c.resetGPUPodClassifiers()
+
+	policy := drainOpts.EvictionPolicy
+	policyFilter := func(pod corev1.Pod) drain.PodDeleteStatus {
+		usesGPU, err := c.podUsesGPU(pod)
+		if err != nil {
+			return drain.MakePodDeleteStatusWithError(err.Error())
+		}
+		if !usesGPU {
+			return drain.MakePodDeleteStatusOkay()
+		}
+		if ok, reason := policy.evictable(pod); !ok {
+			c.log.Warnf("Not evicting GPU pod %s/%s: %s", pod.Namespace, pod.Name, reason)
+			return drain.MakePodDeleteStatusSkip()
+		}
+		return drain.MakePodDeleteStatusOkay()
+	}
 
 	drainHelper := &drain.Helper{
 		Ctx:                c.ctx,
@@ -257,6 +682,7 @@ func (c *Client) DrainNode(nodeName string, drainOpts DrainOptions) error {
 		Force:              drainOpts.Force,
 		DeleteEmptyDirData: drainOpts.DeleteEmptyDirData,
 		Timeout:            drainOpts.Timeout,
+		AdditionalFilters:  []drain.PodFilter{policyFilter},
 	}
 
 	if drainOpts.PodSelector != "" {
@@ -266,11 +692,17 @@ func (c *Client) DrainNode(nodeName string, drainOpts DrainOptions) error {
 	return drain.RunNodeDrain(drainHelper, nodeName)
 }
 
-// DeleteOrEvictPods deletes or evicts the pods on the api server given a Node Name and set of drain option parameters
+// DeleteOrEvictPods deletes or evicts the pods on the api server given a Node Name and set of drain option parameters.
+// GPU pods are evicted in order of increasing priority, and pods kept by the eviction policy are left running.
 func (c *Client) DeleteOrEvictPods(nodeName string, drainOpts DrainOptions) error {
 	c.log.Infof("Draining node %s of any GPU pods", nodeName)
+	c.resetGPUPodClassifiers()
 
+	policy := drainOpts.EvictionPolicy
 	customDrainFilter := func(pod corev1.Pod) drain.PodDeleteStatus {
+		if skipped, _ := skippedByDrain(pod); skipped {
+			return drain.MakePodDeleteStatusSkip()
+		}
 		usesGPU, err := c.podUsesGPU(pod)
 		if err != nil {
 			return drain.MakePodDeleteStatusWithError(err.Error())
@@ -278,6 +710,9 @@ func (c *Client) DeleteOrEvictPods(nodeName string, drainOpts DrainOptions) erro
 		if !usesGPU {
 			return drain.MakePodDeleteStatusSkip()
 		}
+		if ok, _ := policy.evictable(pod); !ok {
+			return drain.MakePodDeleteStatusSkip()
+		}
 		return drain.MakePodDeleteStatusOkay()
 	}
 
@@ -287,7 +722,7 @@ func (c *Client) DeleteOrEvictPods(nodeName string, drainOpts DrainOptions) erro
 		Out:                 os.Stdout,
 		ErrOut:              os.Stderr,
 		ChunkSize:           cmdutil.DefaultChunkSize,
-		GracePeriodSeconds:  -1,
+		GracePeriodSeconds:  drainOpts.GracePeriodSeconds,
 		IgnoreAllDaemonSets: true,
 		DeleteEmptyDirData:  drainOpts.DeleteEmptyDirData,
 		Force:               drainOpts.Force,
@@ -298,24 +733,36 @@ func (c *Client) DeleteOrEvictPods(nodeName string, drainOpts DrainOptions) erro
 	c.log.Infof("Identifying GPU pods to delete")
 
 	// List all pods
-	podList, err := c.clientset.CoreV1().Pods(corev1.NamespaceAll).List(
-		c.ctx,
-		metav1.ListOptions{FieldSelector: "spec.nodeName=" + nodeName},
-	)
+	pods, err := c.ListNodePods(corev1.NamespaceAll, nodeName)
 	if err != nil {
-		return fmt.Errorf("failed to list pods: %v", err)
+		return err
 	}
 
 	// Get number of GPU pods on the node which require deletion
 	numPodsToDelete := 0
-	for _, pod := range podList.Items {
+	var undeletable []string
+	for _, pod := range pods {
 		usesGPU, err := c.podUsesGPU(pod)
 		if err != nil {
 			return fmt.Errorf("failed to check GPU usage for pod %s/%s: %w", pod.Namespace, pod.Name, err)
 		}
-		if usesGPU {
-			numPodsToDelete += 1
+		if !usesGPU {
+			continue
+		}
+		if skipped, reason := skippedByDrain(pod); skipped {
+			undeletable = append(undeletable, fmt.Sprintf("%s/%s (%s)", pod.Namespace, pod.Name, reason))
+			continue
+		}
+		if ok, reason := policy.evictable(pod); !ok {
+			c.log.Warnf("Not evicting GPU pod %s/%s: %s", pod.Namespace, pod.Name, reason)
+			continue
 		}
+		numPodsToDelete += 1
+	}
+
+	if len(undeletable) > 0 {
+		c.log.Error("Cannot delete all GPU pods")
+		return fmt.Errorf("failed to delete all GPU pods: GPU pod(s) cannot be evicted: %s", strings.Join(undeletable, ", "))
 	}
 
 	if numPodsToDelete == 0 {
@@ -333,40 +780,164 @@ func (c *Client) DeleteOrEvictPods(nodeName string, drainOpts DrainOptions) erro
 		return fmt.Errorf("failed to delete all GPU pods")
 	}
 
-	for _, p := range podDeleteList.Pods() {
-		c.log.Infof("GPU pod - %s/%s", p.Namespace, p.Name)
+	groups := groupPodsByPriority(podDeleteList.Pods())
+	for i, group := range groups {
+		c.log.Infof("Deleting GPU pods with priority %d...", podPriority(group[0]))
+		for _, p := range group {
+			c.log.Infof("GPU pod - %s/%s", p.Namespace, p.Name)
+		}
+
+		if err := drainHelper.DeleteOrEvictPods(group); err != nil {
+			return fmt.Errorf("failed to delete all GPU pods: %w", err)
+		}
+
+		if i < len(groups)-1 && policy.PriorityWait > 0 {
+			c.log.Infof("Waiting %s before deleting GPU pods of the next priority", policy.PriorityWait)
+			if err := c.sleep(policy.PriorityWait); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// skippedByDrain reports whether the drain helper never deletes the pod: running DaemonSet pods
+// are ignored and would be recreated anyway, and mirror pods cannot be deleted through the API
+// server. GPU pods skipped by the drain helper keep holding the driver, so DeleteOrEvictPods
+// fails naming them.
+func skippedByDrain(pod corev1.Pod) (bool, string) {
+	if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+		return true, "static pod"
+	}
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false, ""
+	}
+	if owner := metav1.GetControllerOf(&pod); owner != nil && owner.Kind == "DaemonSet" {
+		return true, "managed by DaemonSet " + owner.Name
+	}
+	return false, ""
+}
+
+// sleep pauses for the given duration or until the client context is done
+func (c *Client) sleep(d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-c.ctx.Done():
+		return c.ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// NotifyGPUPodsOfEviction marks every GPU pod on the node with the time after which it will be
+// evicted, and optionally with a pod condition, so that workload controllers get a chance to
+// checkpoint before the GPU is taken away. Pods kept by the eviction policy are not notified.
+// It returns the namespaced names of the notified pods.
+func (c *Client) NotifyGPUPodsOfEviction(nodeName string, deadline time.Time, setCondition bool, policy GPUPodEvictionPolicy) ([]string, error) {
+	gpuPods, err := c.ListGPUPods(nodeName)
+	if err != nil {
+		return nil, err
+	}
+
+	deadlineStr := deadline.UTC().Format(time.RFC3339)
+	var notified []string
+	for _, pod := range gpuPods {
+		if ok, _ := policy.evictable(pod); !ok {
+			continue
+		}
+
+		if err := c.annotatePod(pod, GPUPodEvictionDeadlineAnnotation, deadlineStr); err != nil {
+			return nil, err
+		}
+		if setCondition {
+			if err := c.setPodEvictionPendingCondition(pod, deadlineStr); err != nil {
+				return nil, err
+			}
+		}
+		c.log.Infof("Notified GPU pod %s/%s of its eviction after %s", pod.Namespace, pod.Name, deadlineStr)
+		notified = append(notified, pod.Namespace+"/"+pod.Name)
+	}
+
+	return notified, nil
+}
+
+// annotatePod sets a single annotation on a pod
+func (c *Client) annotatePod(pod corev1.Pod, key, value string) error {
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{key: value},
+		},
+	}
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal patch: %w", err)
 	}
 
-	c.log.Info("Deleting GPU pods...")
-	err = drainHelper.DeleteOrEvictPods(podDeleteList.Pods())
+	err = c.retry(fmt.Sprintf("annotate pod %s/%s", pod.Namespace, pod.Name), func(ctx context.Context) error {
+		_, err := c.clientset.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.StrategicMergePatchType, patchBytes, metav1.PatchOptions{})
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete all GPU pods: %w", err)
+		return fmt.Errorf("failed to annotate pod %s/%s: %w", pod.Namespace, pod.Name, err)
 	}
+	return nil
+}
 
+// setPodEvictionPendingCondition adds the GPUPodEvictionPendingCondition to the status of a pod
+func (c *Client) setPodEvictionPendingCondition(pod corev1.Pod, deadline string) error {
+	condition := corev1.PodCondition{
+		Type:               GPUPodEvictionPendingCondition,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "GPUDriverUpgrade",
+		Message:            fmt.Sprintf("The pod will be evicted after %s for an upgrade of the NVIDIA GPU driver", deadline),
+	}
+	patch := map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []corev1.PodCondition{condition},
+		},
+	}
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal patch: %w", err)
+	}
+
+	err = c.retry(fmt.Sprintf("set condition on pod %s/%s", pod.Namespace, pod.Name), func(ctx context.Context) error {
+		_, err := c.clientset.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.StrategicMergePatchType, patchBytes, metav1.PatchOptions{}, "status")
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set condition on pod %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
 	return nil
 }
 
-// podUsesGPU reports whether a pod uses NVIDIA GPU resources, either via
-// traditional device-plugin resource requests/limits or via DRA ResourceClaims
-// allocated by the NVIDIA GPU DRA driver.
-func (c *Client) podUsesGPU(pod corev1.Pod) (bool, error) {
-	gpuInResourceList := func(rl corev1.ResourceList) bool {
-		for resourceName := range rl {
-			str := string(resourceName)
-			if strings.HasPrefix(str, nvidiaResourceNamePrefix) || strings.HasPrefix(str, nvidiaMigResourcePrefix) {
-				return true
-			}
+// resetGPUPodClassifiers starts a new classification pass by dropping the host state cached
+// by the GPU pod classifiers
+func (c *Client) resetGPUPodClassifiers() {
+	for _, classifier := range c.gpuPodClassifiers {
+		if r, ok := classifier.(resettableClassifier); ok {
+			r.Reset()
 		}
-		return false
 	}
+}
 
-	for _, c := range pod.Spec.Containers {
-		if gpuInResourceList(c.Resources.Limits) || gpuInResourceList(c.Resources.Requests) {
+// podUsesGPU reports whether a pod uses NVIDIA GPU resources according to any of the
+// configured GPU pod classifiers. By default, these recognize traditional device-plugin
+// resource requests/limits and DRA ResourceClaims allocated by the NVIDIA GPU DRA driver.
+func (c *Client) podUsesGPU(pod corev1.Pod) (bool, error) {
+	for _, classifier := range c.gpuPodClassifiers {
+		usesGPU, err := classifier.UsesGPU(pod)
+		if err != nil {
+			return false, err
+		}
+		if usesGPU {
 			return true, nil
 		}
 	}
-
-	return c.podHasGPUResourceClaim(pod)
+	return false, nil
 }
 
 // podHasGPUResourceClaim reports whether the pod holds a ResourceClaim allocated by
@@ -378,7 +949,11 @@ func (c *Client) podHasGPUResourceClaim(pod corev1.Pod) (bool, error) {
 		if claimName == "" {
 			continue
 		}
-		claim, err := c.clientset.ResourceV1().ResourceClaims(pod.Namespace).Get(c.ctx, claimName, metav1.GetOptions{})
+		var claim *resourcev1.ResourceClaim
+		err := c.retry(fmt.Sprintf("get ResourceClaim %s/%s", pod.Namespace, claimName), func(ctx context.Context) (err error) {
+			claim, err = c.clientset.ResourceV1().ResourceClaims(pod.Namespace).Get(ctx, claimName, metav1.GetOptions{})
+			return err
+		})
 		if err != nil {
 			if apierrors.IsNotFound(err) {
 				continue
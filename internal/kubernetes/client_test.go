@@ -0,0 +1,262 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/utils/ptr"
+)
+
+func TestUpdateNodeMetadata(t *testing.T) {
+	const (
+		nodeName    = "gpu-node"
+		deployLabel = "nvidia.com/gpu.deploy.device-plugin"
+		annotation  = "nvidia.com/gpu-driver-upgrade-paused-labels"
+	)
+	value := "{}"
+
+	testCases := []struct {
+		description         string
+		force               bool
+		expectedConflict    bool
+		expectedLabel       string
+		expectedAnnotations map[string]string
+	}{
+		{
+			description:      "conflict with another field manager",
+			expectedConflict: true,
+			expectedLabel:    "true",
+			expectedAnnotations: map[string]string{
+				"legacy": "set before server-side apply",
+			},
+		},
+		{
+			description:   "forced ownership",
+			force:         true,
+			expectedLabel: "paused-for-driver-upgrade",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			clientset := fake.NewClientset(&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        nodeName,
+					Labels:      map[string]string{deployLabel: "true"},
+					Annotations: map[string]string{"legacy": "set before server-side apply"},
+				},
+			})
+			c, err := NewClientFromClientset(context.Background(), clientset, logrus.New(), WithForceFieldOwnership(tc.force))
+			require.NoError(t, err)
+
+			err = c.UpdateNodeMetadata(nodeName,
+				map[string]string{deployLabel: "paused-for-driver-upgrade"},
+				map[string]*string{annotation: &value})
+			if tc.expectedConflict {
+				require.True(t, apierrors.IsConflict(err), "unexpected error: %v", err)
+			} else {
+				require.NoError(t, err)
+
+				node, err := c.GetNode(nodeName)
+				require.NoError(t, err)
+				var applied []metav1.ManagedFieldsEntry
+				for _, entry := range node.ManagedFields {
+					if entry.Manager == FieldManager && entry.Operation == metav1.ManagedFieldsOperationApply {
+						applied = append(applied, entry)
+					}
+				}
+				require.Len(t, applied, 1)
+				node.ManagedFields = applied
+				_, owned := FieldLastSetTime(node, "metadata", "labels", deployLabel)
+				require.True(t, owned, "label is not owned by %s", FieldManager)
+
+				// Owned and legacy annotations are both removed
+				require.NoError(t, c.UpdateNodeAnnotations(nodeName, map[string]*string{annotation: nil, "legacy": nil}))
+			}
+
+			node, err := c.GetNode(nodeName)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedLabel, node.Labels[deployLabel])
+			if tc.expectedAnnotations == nil {
+				require.Empty(t, node.Annotations)
+			} else {
+				require.Equal(t, tc.expectedAnnotations, node.Annotations)
+			}
+		})
+	}
+}
+
+// newTestPod returns a running pod on the node, owned by a controller of the given kind
+func newTestPod(name, nodeName, ownerKind string) *corev1.Pod {
+	controller := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      name,
+			UID:       types.UID("uid-" + name),
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       ownerKind,
+				Name:       name + "-owner",
+				Controller: &controller,
+			}},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:   nodeName,
+			Containers: []corev1.Container{{Name: "main"}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func TestDeleteOrEvictPods(t *testing.T) {
+	const nodeName = "gpu-node"
+	privileged := true
+
+	gpuPod := newTestPod("training", nodeName, "ReplicaSet")
+	gpuPod.Spec.Containers[0].Resources.Limits = corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}
+
+	// A node agent mounting the whole of /dev into a privileged container is classified as a
+	// GPU pod, but is never deleted by the drain helper and keeps holding the driver
+	devPod := newTestPod("node-exporter", nodeName, "DaemonSet")
+	devPod.Spec.Volumes = []corev1.Volume{{
+		Name:         "dev",
+		VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/dev"}},
+	}}
+	devPod.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{{Name: "dev", MountPath: "/host/dev"}}
+	devPod.Spec.Containers[0].SecurityContext = &corev1.SecurityContext{Privileged: &privileged}
+	devDaemonSet := &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "node-exporter-owner"}}
+
+	cpuPod := newTestPod("web", nodeName, "ReplicaSet")
+
+	testCases := []struct {
+		description       string
+		objects           []runtime.Object
+		drainOpts         DrainOptions
+		expectedDeleted   []string
+		expectedRemaining []string
+		expectedError     string
+		// expectedGracePeriod is the grace period the GPU pods are deleted with, nil for the
+		// grace period of each pod
+		expectedGracePeriod *int64
+	}{
+		{
+			description:       "GPU pods are deleted",
+			objects:           []runtime.Object{gpuPod, cpuPod},
+			drainOpts:         DrainOptions{GracePeriodSeconds: -1},
+			expectedDeleted:   []string{"training"},
+			expectedRemaining: []string{"web"},
+		},
+		{
+			description:         "GPU pods are deleted with the configured grace period",
+			objects:             []runtime.Object{gpuPod},
+			drainOpts:           DrainOptions{GracePeriodSeconds: 30},
+			expectedDeleted:     []string{"training"},
+			expectedGracePeriod: ptr.To[int64](30),
+		},
+		{
+			description:         "GPU pods are deleted immediately with a zero grace period",
+			objects:             []runtime.Object{gpuPod},
+			drainOpts:           DrainOptions{GracePeriodSeconds: 0},
+			expectedDeleted:     []string{"training"},
+			expectedGracePeriod: ptr.To[int64](0),
+		},
+		{
+			description:       "privileged /dev DaemonSet pod fails the eviction",
+			objects:           []runtime.Object{gpuPod, devPod, devDaemonSet},
+			drainOpts:         DrainOptions{GracePeriodSeconds: -1},
+			expectedRemaining: []string{"training", "node-exporter"},
+			expectedError:     "GPU pod(s) cannot be evicted: default/node-exporter (managed by DaemonSet node-exporter-owner)",
+		},
+		{
+			description:       "GPU pods kept by the eviction policy are left running",
+			objects:           []runtime.Object{gpuPod, cpuPod},
+			drainOpts:         DrainOptions{GracePeriodSeconds: -1, EvictionPolicy: GPUPodEvictionPolicy{RequireOptIn: true}},
+			expectedRemaining: []string{"training", "web"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			clientset := fake.NewClientset(tc.objects...)
+			// Advertise the core API without the eviction subresource so that the drain
+			// helper falls back to deleting pods, which the fake clientset supports.
+			clientset.Resources = []*metav1.APIResourceList{{
+				GroupVersion: "v1",
+				APIResources: []metav1.APIResource{{Name: "pods", Namespaced: true, Kind: "Pod"}},
+			}}
+			c, err := NewClientFromClientset(context.Background(), clientset, logrus.New(),
+				WithPollInterval(10*time.Millisecond), WithGPUPodClassifiers(NewDeviceMountClassifier()))
+			require.NoError(t, err)
+
+			err = c.DeleteOrEvictPods(nodeName, tc.drainOpts)
+			if tc.expectedError != "" {
+				require.ErrorContains(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			for _, name := range tc.expectedDeleted {
+				_, err := clientset.CoreV1().Pods("default").Get(context.Background(), name, metav1.GetOptions{})
+				require.True(t, apierrors.IsNotFound(err), "pod %s was not deleted", name)
+			}
+			for _, action := range clientset.Actions() {
+				if deleteAction, ok := action.(k8stesting.DeleteAction); ok && action.GetResource().Resource == "pods" {
+					require.Equal(t, tc.expectedGracePeriod, deleteAction.GetDeleteOptions().GracePeriodSeconds)
+				}
+			}
+			for _, name := range tc.expectedRemaining {
+				_, err := clientset.CoreV1().Pods("default").Get(context.Background(), name, metav1.GetOptions{})
+				require.NoError(t, err, "pod %s was deleted", name)
+			}
+		})
+	}
+}
+
+func TestListGPUPodsRescansHostProcesses(t *testing.T) {
+	const nodeName = "gpu-node"
+	pod := newTestPod("training", nodeName, "ReplicaSet")
+
+	var uids []string
+	c, err := NewClientFromClientset(context.Background(), fake.NewClientset(pod), logrus.New(),
+		WithGPUPodClassifiers(NewPodUIDClassifier(func() ([]string, error) { return uids, nil })))
+	require.NoError(t, err)
+
+	gpuPods, err := c.ListGPUPods(nodeName)
+	require.NoError(t, err)
+	require.Empty(t, gpuPods)
+
+	// The pod opens the GPU after the first scan
+	uids = []string{string(pod.UID)}
+	gpuPods, err = c.ListGPUPods(nodeName)
+	require.NoError(t, err)
+	require.Len(t, gpuPods, 1)
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// GPUPodEvictionOptInAnnotation marks a GPU pod as evictable by driver-manager when the
+	// eviction policy requires an explicit opt-in.
+	GPUPodEvictionOptInAnnotation = nvidiaDomainPrefix + "/" + "gpu-driver-upgrade-evict"
+	// GPUPodNeverEvictAnnotation marks a GPU pod as never to be evicted by driver-manager.
+	GPUPodNeverEvictAnnotation = nvidiaDomainPrefix + "/" + "gpu-driver-upgrade-never-evict"
+)
+
+// GPUPodEvictionPolicy decides which GPU pods driver-manager may evict and in which order
+type GPUPodEvictionPolicy struct {
+	// ExcludedNamespaces lists the namespaces whose GPU pods are never evicted
+	ExcludedNamespaces []string
+	// RequireOptIn restricts eviction to GPU pods annotated with GPUPodEvictionOptInAnnotation=true
+	RequireOptIn bool
+	// PriorityWait is the time to wait after evicting the GPU pods of one priority before
+	// evicting those of the next higher priority
+	PriorityWait time.Duration
+}
+
+// evictable reports whether the policy allows the eviction of a GPU pod, and if not, why
+func (p *GPUPodEvictionPolicy) evictable(pod corev1.Pod) (bool, string) {
+	if pod.Annotations[GPUPodNeverEvictAnnotation] == "true" {
+		return false, fmt.Sprintf("pod is annotated with %s=true", GPUPodNeverEvictAnnotation)
+	}
+	if slices.Contains(p.ExcludedNamespaces, pod.Namespace) {
+		return false, fmt.Sprintf("namespace %s is excluded from GPU pod eviction", pod.Namespace)
+	}
+	if p.RequireOptIn && pod.Annotations[GPUPodEvictionOptInAnnotation] != "true" {
+		return false, fmt.Sprintf("pod is not annotated with %s=true", GPUPodEvictionOptInAnnotation)
+	}
+	return true, ""
+}
+
+// podPriority returns the priority resolved from the PriorityClass of a pod
+func podPriority(pod corev1.Pod) int32 {
+	if pod.Spec.Priority == nil {
+		return 0
+	}
+	return *pod.Spec.Priority
+}
+
+// groupPodsByPriority splits pods into groups of equal priority ordered from the lowest
+// priority to the highest
+func groupPodsByPriority(pods []corev1.Pod) [][]corev1.Pod {
+	sorted := slices.Clone(pods)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return podPriority(sorted[i]) < podPriority(sorted[j])
+	})
+
+	var groups [][]corev1.Pod
+	for i, pod := range sorted {
+		if i == 0 || podPriority(pod) != podPriority(sorted[i-1]) {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], pod)
+	}
+	return groups
+}
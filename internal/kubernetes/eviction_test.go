@@ -0,0 +1,198 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newPolicyTestPod(namespace, name string, priority *int32, annotations map[string]string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   namespace,
+			Name:        name,
+			Annotations: annotations,
+		},
+		Spec: corev1.PodSpec{
+			Priority: priority,
+		},
+	}
+}
+
+func TestGPUPodEvictionPolicyEvictable(t *testing.T) {
+	testCases := []struct {
+		description       string
+		policy            GPUPodEvictionPolicy
+		pod               corev1.Pod
+		expectedEvictable bool
+	}{
+		{
+			description:       "default policy",
+			pod:               newPolicyTestPod("default", "pod", nil, nil),
+			expectedEvictable: true,
+		},
+		{
+			description:       "never evict annotation",
+			pod:               newPolicyTestPod("default", "pod", nil, map[string]string{GPUPodNeverEvictAnnotation: "true"}),
+			expectedEvictable: false,
+		},
+		{
+			description:       "excluded namespace",
+			policy:            GPUPodEvictionPolicy{ExcludedNamespaces: []string{"inference"}},
+			pod:               newPolicyTestPod("inference", "pod", nil, nil),
+			expectedEvictable: false,
+		},
+		{
+			description:       "opt-in required, not annotated",
+			policy:            GPUPodEvictionPolicy{RequireOptIn: true},
+			pod:               newPolicyTestPod("default", "pod", nil, nil),
+			expectedEvictable: false,
+		},
+		{
+			description:       "opt-in required, annotated",
+			policy:            GPUPodEvictionPolicy{RequireOptIn: true},
+			pod:               newPolicyTestPod("default", "pod", nil, map[string]string{GPUPodEvictionOptInAnnotation: "true"}),
+			expectedEvictable: true,
+		},
+		{
+			description: "opt-in annotated but never evict wins",
+			policy:      GPUPodEvictionPolicy{RequireOptIn: true},
+			pod: newPolicyTestPod("default", "pod", nil, map[string]string{
+				GPUPodEvictionOptInAnnotation: "true",
+				GPUPodNeverEvictAnnotation:    "true",
+			}),
+			expectedEvictable: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			evictable, _ := tc.policy.evictable(tc.pod)
+			require.Equal(t, tc.expectedEvictable, evictable)
+		})
+	}
+}
+
+func TestGroupPodsByPriority(t *testing.T) {
+	low, high := int32(-10), int32(1000)
+	pods := []corev1.Pod{
+		newPolicyTestPod("default", "critical", &high, nil),
+		newPolicyTestPod("default", "default-a", nil, nil),
+		newPolicyTestPod("default", "batch", &low, nil),
+		newPolicyTestPod("default", "default-b", nil, nil),
+	}
+
+	groups := groupPodsByPriority(pods)
+
+	var names [][]string
+	for _, group := range groups {
+		var groupNames []string
+		for _, pod := range group {
+			groupNames = append(groupNames, pod.Name)
+		}
+		names = append(names, groupNames)
+	}
+	require.Equal(t, [][]string{{"batch"}, {"default-a", "default-b"}, {"critical"}}, names)
+}
+
+func TestNotifyGPUPodsOfEviction(t *testing.T) {
+	const nodeName = "gpu-node"
+	deadline := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	newGPUPod := func(namespace, name string, annotations map[string]string) *corev1.Pod {
+		pod := newTestPod(name, nodeName, "ReplicaSet")
+		pod.Namespace = namespace
+		pod.Annotations = annotations
+		pod.Spec.Containers[0].Resources.Limits = corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}
+		return pod
+	}
+
+	testCases := []struct {
+		description  string
+		setCondition bool
+	}{
+		{description: "annotation only"},
+		{description: "annotation and condition", setCondition: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			clientset := fake.NewClientset(
+				newGPUPod("default", "training", nil),
+				newGPUPod("default", "inference", map[string]string{GPUPodNeverEvictAnnotation: "true"}),
+				newGPUPod("kube-system", "monitoring", nil),
+				newTestPod("web", nodeName, "ReplicaSet"),
+			)
+			c, err := NewClientFromClientset(context.Background(), clientset, logrus.New())
+			require.NoError(t, err)
+
+			policy := GPUPodEvictionPolicy{ExcludedNamespaces: []string{"kube-system"}}
+			notified, err := c.NotifyGPUPodsOfEviction(nodeName, deadline, tc.setCondition, policy)
+			require.NoError(t, err)
+			require.Equal(t, []string{"default/training"}, notified)
+
+			statusPatches := 0
+			for _, action := range clientset.Actions() {
+				if action.GetVerb() == "patch" && action.GetSubresource() == "status" {
+					statusPatches++
+				}
+			}
+			if tc.setCondition {
+				require.Equal(t, 1, statusPatches, "condition not set through the status subresource")
+			} else {
+				require.Zero(t, statusPatches)
+			}
+
+			pods := map[string]string{"training": "default", "inference": "default", "monitoring": "kube-system", "web": "default"}
+			for name, namespace := range pods {
+				pod, err := clientset.CoreV1().Pods(namespace).Get(context.Background(), name, metav1.GetOptions{})
+				require.NoError(t, err)
+
+				var condition *corev1.PodCondition
+				for i := range pod.Status.Conditions {
+					if pod.Status.Conditions[i].Type == GPUPodEvictionPendingCondition {
+						condition = &pod.Status.Conditions[i]
+					}
+				}
+				if name != "training" {
+					require.NotContains(t, pod.Annotations, GPUPodEvictionDeadlineAnnotation, "pod %s", name)
+					require.Nil(t, condition, "pod %s", name)
+					continue
+				}
+
+				require.Equal(t, "2026-10-18T12:00:00Z", pod.Annotations[GPUPodEvictionDeadlineAnnotation])
+				if !tc.setCondition {
+					require.Nil(t, condition)
+					continue
+				}
+				require.NotNil(t, condition)
+				require.Equal(t, corev1.ConditionTrue, condition.Status)
+				require.Equal(t, "GPUDriverUpgrade", condition.Reason)
+				require.Contains(t, condition.Message, "2026-10-18T12:00:00Z")
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const upgradeSlotLeasePrefix = "nvidia-driver-upgrade-slot-"
+
+// errSlotTakenOver is returned when renewing a slot whose Lease is held by another holder
+var errSlotTakenOver = errors.New("slot has been taken over by another holder")
+
+// UpgradeSlot is one of a limited number of cluster-wide slots allowing a node to perform a
+// disruptive driver upgrade. Each slot is backed by a coordination.k8s.io Lease which is
+// renewed in the background for as long as the slot is held.
+type UpgradeSlot struct {
+	client       *Client
+	namespace    string
+	name         string
+	holder       string
+	staleTimeout time.Duration
+
+	stopRenewal context.CancelFunc
+	releaseOnce sync.Once
+
+	mu sync.Mutex
+	// renewed is the time the Lease was last acquired or renewed
+	renewed time.Time
+	// lost is set once the slot has been taken over by another holder
+	lost error
+}
+
+// AcquireUpgradeSlot blocks until the holder acquires one of the given number of upgrade
+// slots in the namespace. A slot whose Lease has not been renewed within staleTimeout is
+// taken over, since its holder is assumed to have died mid-upgrade.
+func (c *Client) AcquireUpgradeSlot(namespace, holder string, slots int, staleTimeout time.Duration) (*UpgradeSlot, error) {
+	c.log.Infof("Acquiring one of %d driver upgrade slot(s) for %s", slots, holder)
+
+	var acquired string
+	err := wait.PollUntilContextCancel(c.ctx, c.pollInterval, true, func(ctx context.Context) (bool, error) {
+		for i := 0; i < slots; i++ {
+			name := fmt.Sprintf("%s%d", upgradeSlotLeasePrefix, i)
+			ok, err := c.tryAcquireLease(namespace, name, holder, staleTimeout)
+			if err != nil {
+				c.log.Warnf("Failed to acquire driver upgrade slot %s: %v", name, err)
+				continue
+			}
+			if ok {
+				acquired = name
+				return true, nil
+			}
+		}
+		c.log.Infof("All %d driver upgrade slot(s) are held by other nodes, waiting...", slots)
+		return false, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire a driver upgrade slot: %w", err)
+	}
+	c.log.Infof("Acquired driver upgrade slot %s/%s", namespace, acquired)
+
+	renewCtx, cancel := context.WithCancel(c.ctx)
+	slot := &UpgradeSlot{
+		client:       c,
+		namespace:    namespace,
+		name:         acquired,
+		holder:       holder,
+		staleTimeout: staleTimeout,
+		stopRenewal:  cancel,
+		renewed:      time.Now(),
+	}
+	go slot.keepRenewed(renewCtx, staleTimeout/3)
+
+	return slot, nil
+}
+
+// tryAcquireLease takes the named Lease for the holder if it is free, stale or already
+// held by the holder. Concurrent takeovers are resolved by the optimistic concurrency of
+// the Lease update.
+func (c *Client) tryAcquireLease(namespace, name, holder string, staleTimeout time.Duration) (bool, error) {
+	leases := c.clientset.CoordinationV1().Leases(namespace)
+	now := metav1.NewMicroTime(time.Now())
+	durationSeconds := int32(math.Ceil(staleTimeout.Seconds()))
+
+	lease, err := leases.Get(c.ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       &holder,
+				LeaseDurationSeconds: &durationSeconds,
+				AcquireTime:          &now,
+				RenewTime:            &now,
+			},
+		}
+		_, err := leases.Create(c.ctx, lease, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	if err != nil {
+		return false, err
+	}
+
+	currentHolder := ""
+	if lease.Spec.HolderIdentity != nil {
+		currentHolder = *lease.Spec.HolderIdentity
+	}
+	switch {
+	case currentHolder == "" || currentHolder == holder:
+	case leaseExpired(lease, time.Now()):
+		c.log.Warnf("Taking over stale driver upgrade slot %s/%s from %s", namespace, name, currentHolder)
+	default:
+		return false, nil
+	}
+
+	lease.Spec.HolderIdentity = &holder
+	lease.Spec.LeaseDurationSeconds = &durationSeconds
+	lease.Spec.RenewTime = &now
+	if currentHolder != holder {
+		lease.Spec.AcquireTime = &now
+	}
+	_, err = leases.Update(c.ctx, lease, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// leaseExpired reports whether a Lease has not been renewed within its duration
+func leaseExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+	expiry := lease.Spec.RenewTime.Add(time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second)
+	return now.After(expiry)
+}
+
+// keepRenewed renews the Lease backing the slot every period until the context is done or the
+// slot is taken over by another holder
+func (s *UpgradeSlot) keepRenewed(ctx context.Context, period time.Duration) {
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := s.renew()
+		s.mu.Lock()
+		switch {
+		case errors.Is(err, errSlotTakenOver):
+			s.client.log.Errorf("Lost driver upgrade slot %s/%s: %v", s.namespace, s.name, err)
+			s.lost = err
+		case err != nil:
+			s.client.log.Warnf("Failed to renew driver upgrade slot %s/%s: %v", s.namespace, s.name, err)
+		default:
+			s.renewed = time.Now()
+		}
+		lost := s.lost != nil
+		s.mu.Unlock()
+		if lost {
+			return
+		}
+	}
+}
+
+// renew extends the Lease backing the slot
+func (s *UpgradeSlot) renew() error {
+	leases := s.client.clientset.CoordinationV1().Leases(s.namespace)
+	lease, err := leases.Get(s.client.ctx, s.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != s.holder {
+		return errSlotTakenOver
+	}
+	now := metav1.NewMicroTime(time.Now())
+	lease.Spec.RenewTime = &now
+	_, err = leases.Update(s.client.ctx, lease, metav1.UpdateOptions{})
+	return err
+}
+
+// Check returns an error if the slot may no longer be held, i.e. if it has been taken over by
+// another holder or has not been renewed within the stale timeout. Disruptive steps of the
+// driver upgrade must not be performed without holding the slot.
+func (s *UpgradeSlot) Check() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lost != nil {
+		return fmt.Errorf("lost driver upgrade slot %s/%s: %w", s.namespace, s.name, s.lost)
+	}
+	if since := time.Since(s.renewed); since > s.staleTimeout {
+		return fmt.Errorf("driver upgrade slot %s/%s has not been renewed for %s and may have been taken over", s.namespace, s.name, since.Round(time.Second))
+	}
+	return nil
+}
+
+// Release frees the slot for other nodes. It is safe to call Release more than once.
+func (s *UpgradeSlot) Release() error {
+	return s.ReleaseWithContext(s.client.ctx)
+}
+
+// ReleaseWithContext frees the slot for other nodes using the given context, which allows
+// releasing the slot after the context it was acquired with has been cancelled.
+func (s *UpgradeSlot) ReleaseWithContext(ctx context.Context) error {
+	var err error
+	s.releaseOnce.Do(func() {
+		s.stopRenewal()
+
+		leases := s.client.clientset.CoordinationV1().Leases(s.namespace)
+		var lease *coordinationv1.Lease
+		lease, err = leases.Get(ctx, s.name, metav1.GetOptions{})
+		if err != nil {
+			err = fmt.Errorf("failed to get lease %s/%s: %w", s.namespace, s.name, err)
+			return
+		}
+		if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != s.holder {
+			return
+		}
+		lease.Spec.HolderIdentity = nil
+		lease.Spec.AcquireTime = nil
+		lease.Spec.RenewTime = nil
+		if _, err = leases.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+			err = fmt.Errorf("failed to release lease %s/%s: %w", s.namespace, s.name, err)
+			return
+		}
+		s.client.log.Infof("Released driver upgrade slot %s/%s", s.namespace, s.name)
+	})
+	return err
+}
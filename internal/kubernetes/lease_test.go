@@ -0,0 +1,259 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const (
+	testLeaseNamespace = "gpu-operator"
+	testLeaseHolder    = "gpu-node-1"
+)
+
+// newTestLease returns the Lease backing an upgrade slot, held by the holder since renewed
+func newTestLease(slot, holder string, renewed time.Time) *coordinationv1.Lease {
+	renewTime := metav1.NewMicroTime(renewed)
+	durationSeconds := int32(60)
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: upgradeSlotLeasePrefix + slot, Namespace: testLeaseNamespace},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &holder,
+			LeaseDurationSeconds: &durationSeconds,
+			AcquireTime:          &renewTime,
+			RenewTime:            &renewTime,
+		},
+	}
+}
+
+func getTestLease(t *testing.T, clientset *fake.Clientset, slot string) *coordinationv1.Lease {
+	lease, err := clientset.CoordinationV1().Leases(testLeaseNamespace).Get(context.Background(), upgradeSlotLeasePrefix+slot, metav1.GetOptions{})
+	require.NoError(t, err)
+	return lease
+}
+
+func TestAcquireUpgradeSlot(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		description string
+		slots       int
+		leases      []runtime.Object
+		// lostRace has another holder win the update of the Lease of slot 0
+		lostRace      bool
+		expectedSlot  string
+		expectedError bool
+	}{
+		{
+			description:  "free slot",
+			slots:        1,
+			expectedSlot: "0",
+		},
+		{
+			description:  "released slot",
+			slots:        1,
+			leases:       []runtime.Object{newTestLease("0", "", now)},
+			expectedSlot: "0",
+		},
+		{
+			description:  "slot already held by the holder",
+			slots:        1,
+			leases:       []runtime.Object{newTestLease("0", testLeaseHolder, now)},
+			expectedSlot: "0",
+		},
+		{
+			description:  "next free slot",
+			slots:        2,
+			leases:       []runtime.Object{newTestLease("0", "gpu-node-2", now)},
+			expectedSlot: "1",
+		},
+		{
+			description:  "expired lease is taken over",
+			slots:        1,
+			leases:       []runtime.Object{newTestLease("0", "gpu-node-2", now.Add(-time.Hour))},
+			expectedSlot: "0",
+		},
+		{
+			description:  "takeover of an expired lease lost to another holder",
+			slots:        2,
+			leases:       []runtime.Object{newTestLease("0", "gpu-node-2", now.Add(-time.Hour))},
+			lostRace:     true,
+			expectedSlot: "1",
+		},
+		{
+			description:   "all slots held",
+			slots:         2,
+			leases:        []runtime.Object{newTestLease("0", "gpu-node-2", now), newTestLease("1", "gpu-node-3", now)},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			clientset := fake.NewClientset(tc.leases...)
+			if tc.lostRace {
+				clientset.PrependReactor("update", "leases", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					lease := action.(k8stesting.UpdateAction).GetObject().(*coordinationv1.Lease)
+					if lease.Name != upgradeSlotLeasePrefix+"0" {
+						return false, nil, nil
+					}
+					winner := newTestLease("0", "gpu-node-3", time.Now())
+					require.NoError(t, clientset.Tracker().Update(coordinationv1.SchemeGroupVersion.WithResource("leases"), winner, testLeaseNamespace))
+					return true, nil, apierrors.NewConflict(schema.GroupResource{Group: "coordination.k8s.io", Resource: "leases"}, lease.Name, nil)
+				})
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			c, err := NewClientFromClientset(ctx, clientset, logrus.New(), WithPollInterval(10*time.Millisecond))
+			require.NoError(t, err)
+
+			slot, err := c.AcquireUpgradeSlot(testLeaseNamespace, testLeaseHolder, tc.slots, time.Minute)
+			if tc.expectedError {
+				require.Error(t, err)
+				for i, holder := range []string{"gpu-node-2", "gpu-node-3"} {
+					require.Equal(t, holder, *getTestLease(t, clientset, strconv.Itoa(i)).Spec.HolderIdentity)
+				}
+				return
+			}
+			require.NoError(t, err)
+			defer func() { _ = slot.ReleaseWithContext(context.Background()) }()
+
+			require.Equal(t, upgradeSlotLeasePrefix+tc.expectedSlot, slot.name)
+			lease := getTestLease(t, clientset, tc.expectedSlot)
+			require.Equal(t, testLeaseHolder, *lease.Spec.HolderIdentity)
+			require.Equal(t, int32(60), *lease.Spec.LeaseDurationSeconds)
+			require.False(t, leaseExpired(lease, time.Now()))
+		})
+	}
+}
+
+func TestUpgradeSlotRenewal(t *testing.T) {
+	clientset := fake.NewClientset()
+	c, err := NewClientFromClientset(context.Background(), clientset, logrus.New(), WithPollInterval(10*time.Millisecond))
+	require.NoError(t, err)
+
+	slot, err := c.AcquireUpgradeSlot(testLeaseNamespace, testLeaseHolder, 1, 300*time.Millisecond)
+	require.NoError(t, err)
+	defer func() { _ = slot.Release() }()
+
+	acquired := getTestLease(t, clientset, "0").Spec.RenewTime.Time
+	require.Eventually(t, func() bool {
+		return getTestLease(t, clientset, "0").Spec.RenewTime.After(acquired)
+	}, 2*time.Second, 10*time.Millisecond, "lease not renewed")
+
+	require.NoError(t, slot.Check())
+
+	// A slot taken over by another holder is not renewed, and is reported as lost
+	require.NoError(t, clientset.Tracker().Update(coordinationv1.SchemeGroupVersion.WithResource("leases"),
+		newTestLease("0", "gpu-node-2", time.Now()), testLeaseNamespace))
+	require.Eventually(t, func() bool {
+		return slot.Check() != nil
+	}, 2*time.Second, 10*time.Millisecond, "lost slot not reported")
+	require.ErrorIs(t, slot.Check(), errSlotTakenOver)
+	require.Equal(t, "gpu-node-2", *getTestLease(t, clientset, "0").Spec.HolderIdentity)
+}
+
+func TestUpgradeSlotNotRenewed(t *testing.T) {
+	clientset := fake.NewClientset()
+	c, err := NewClientFromClientset(context.Background(), clientset, logrus.New(), WithPollInterval(10*time.Millisecond))
+	require.NoError(t, err)
+
+	// Renewals fail, e.g. while the API server is unreachable
+	var unavailable atomic.Bool
+	clientset.PrependReactor("update", "leases", func(k8stesting.Action) (bool, runtime.Object, error) {
+		if unavailable.Load() {
+			return true, nil, apierrors.NewServiceUnavailable("unavailable")
+		}
+		return false, nil, nil
+	})
+
+	slot, err := c.AcquireUpgradeSlot(testLeaseNamespace, testLeaseHolder, 1, 300*time.Millisecond)
+	require.NoError(t, err)
+	defer func() { _ = slot.Release() }()
+	require.NoError(t, slot.Check())
+
+	unavailable.Store(true)
+	require.Eventually(t, func() bool {
+		return slot.Check() != nil
+	}, 2*time.Second, 10*time.Millisecond, "stale slot not reported")
+	require.ErrorContains(t, slot.Check(), "has not been renewed")
+}
+
+func TestUpgradeSlotRelease(t *testing.T) {
+	testCases := []struct {
+		description    string
+		takenOver      bool
+		expectedHolder string
+	}{
+		{
+			description: "held slot is released",
+		},
+		{
+			description:    "slot taken over by another holder is left alone",
+			takenOver:      true,
+			expectedHolder: "gpu-node-2",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			clientset := fake.NewClientset()
+			ctx, cancel := context.WithCancel(context.Background())
+			c, err := NewClientFromClientset(ctx, clientset, logrus.New(), WithPollInterval(10*time.Millisecond))
+			require.NoError(t, err)
+
+			slot, err := c.AcquireUpgradeSlot(testLeaseNamespace, testLeaseHolder, 1, time.Minute)
+			require.NoError(t, err)
+			if tc.takenOver {
+				require.NoError(t, clientset.Tracker().Update(coordinationv1.SchemeGroupVersion.WithResource("leases"),
+					newTestLease("0", "gpu-node-2", time.Now()), testLeaseNamespace))
+			}
+
+			// The slot is released after the context it was acquired with has been cancelled
+			cancel()
+			require.NoError(t, slot.ReleaseWithContext(context.Background()))
+			lease := getTestLease(t, clientset, "0")
+			if tc.expectedHolder == "" {
+				require.Nil(t, lease.Spec.HolderIdentity)
+				require.Nil(t, lease.Spec.RenewTime)
+			} else {
+				require.Equal(t, tc.expectedHolder, *lease.Spec.HolderIdentity)
+			}
+
+			// Releasing again does not touch the Lease
+			clientset.ClearActions()
+			require.NoError(t, slot.ReleaseWithContext(context.Background()))
+			require.NoError(t, slot.Release())
+			require.Empty(t, clientset.Actions())
+		})
+	}
+}
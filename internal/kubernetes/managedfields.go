@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes
+
+import (
+	"encoding/json"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FieldLastSetTime returns the time at which a field of the object was last set according to
+// its managed fields, e.g. ("metadata", "labels", "nvidia.com/gpu.deploy.driver"). It returns
+// false if no field manager owns the field.
+func FieldLastSetTime(obj metav1.Object, fieldPath ...string) (time.Time, bool) {
+	var lastSet time.Time
+	found := false
+	for _, entry := range obj.GetManagedFields() {
+		if entry.FieldsV1 == nil || entry.Time == nil {
+			continue
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		if !ownsField(fields, fieldPath) {
+			continue
+		}
+		if !found || entry.Time.After(lastSet) {
+			lastSet = entry.Time.Time
+			found = true
+		}
+	}
+	return lastSet, found
+}
+
+// ownsField reports whether the fieldsV1 set contains the field path
+func ownsField(fields map[string]interface{}, fieldPath []string) bool {
+	for _, name := range fieldPath {
+		child, ok := fields["f:"+name].(map[string]interface{})
+		if !ok {
+			return false
+		}
+		fields = child
+	}
+	return true
+}
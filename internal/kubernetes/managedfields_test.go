@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFieldLastSetTime(t *testing.T) {
+	older := metav1.NewTime(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	newer := metav1.NewTime(time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC))
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				{
+					Manager:  "kubelet",
+					Time:     &older,
+					FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:labels":{"f:kubernetes.io/hostname":{}}}}`)},
+				},
+				{
+					Manager:  "k8s-driver-manager",
+					Time:     &older,
+					FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:labels":{"f:nvidia.com/gpu.deploy.driver":{}}}}`)},
+				},
+				{
+					Manager:  "kubectl-label",
+					Time:     &newer,
+					FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:metadata":{"f:labels":{"f:nvidia.com/gpu.deploy.driver":{}}},"f:spec":{"f:unschedulable":{}}}`)},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		description   string
+		fieldPath     []string
+		expectedFound bool
+		expectedTime  time.Time
+	}{
+		{"latest of several managers", []string{"metadata", "labels", "nvidia.com/gpu.deploy.driver"}, true, newer.Time},
+		{"single manager", []string{"metadata", "labels", "kubernetes.io/hostname"}, true, older.Time},
+		{"spec field", []string{"spec", "unschedulable"}, true, newer.Time},
+		{"unmanaged field", []string{"metadata", "labels", "nvidia.com/gpu.deploy.device-plugin"}, false, time.Time{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			lastSet, found := FieldLastSetTime(node, tc.fieldPath...)
+			require.Equal(t, tc.expectedFound, found)
+			require.Equal(t, tc.expectedTime, lastSet)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ErrNodeModified is returned by UpdateNodeMetadataAt when the Node has been modified since
+// the snapshot the update was computed from was taken
+var ErrNodeModified = errors.New("node has been modified since it was read")
+
+// NodeSnapshot is a copy of the state of a Node at a given resourceVersion, which allows
+// working from a single read of the Node and detecting concurrent modifications on update
+type NodeSnapshot struct {
+	Name            string
+	ResourceVersion string
+	Labels          map[string]string
+	Annotations     map[string]string
+	Taints          []corev1.Taint
+	Unschedulable   bool
+	Allocatable     corev1.ResourceList
+}
+
+// NewNodeSnapshot returns a snapshot of the given Node
+func NewNodeSnapshot(node *corev1.Node) *NodeSnapshot {
+	snapshot := &NodeSnapshot{
+		Name:            node.Name,
+		ResourceVersion: node.ResourceVersion,
+		Labels:          maps.Clone(node.Labels),
+		Annotations:     maps.Clone(node.Annotations),
+		Taints:          slices.Clone(node.Spec.Taints),
+		Unschedulable:   node.Spec.Unschedulable,
+		Allocatable:     node.Status.Allocatable.DeepCopy(),
+	}
+	if snapshot.Labels == nil {
+		snapshot.Labels = make(map[string]string)
+	}
+	if snapshot.Annotations == nil {
+		snapshot.Annotations = make(map[string]string)
+	}
+	return snapshot
+}
+
+// GetNodeSnapshot returns a snapshot of a Node given a Node name
+func (c *Client) GetNodeSnapshot(nodeName string) (*NodeSnapshot, error) {
+	node, err := c.GetNode(nodeName)
+	if err != nil {
+		return nil, err
+	}
+	return NewNodeSnapshot(node), nil
+}
+
+// UpdateNodeMetadataAt updates the labels and annotations on a Node like UpdateNodeMetadata,
+// provided the Node has not been modified since the given snapshot was taken. It returns a
+// snapshot of the updated Node, or an error wrapping ErrNodeModified if the Node has been
+// modified, in which case the update should be computed again from a new snapshot.
+func (c *Client) UpdateNodeMetadataAt(snapshot *NodeSnapshot, nodeLabels map[string]string, annotations map[string]*string) (*NodeSnapshot, error) {
+	var updated *corev1.Node
+	err := c.retry("update metadata of node "+snapshot.Name, func(ctx context.Context) (err error) {
+		updated, err = c.applyNodeMetadata(ctx, snapshot.Name, snapshot.ResourceVersion, nodeLabels, annotations)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update metadata of node %s: %w", snapshot.Name, err)
+	}
+	return NewNodeSnapshot(updated), nil
+}
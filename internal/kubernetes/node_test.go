@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestUpdateNodeMetadataAt(t *testing.T) {
+	const (
+		nodeName = "gpu-node"
+		label    = "nvidia.com/gpu.deploy.device-plugin"
+	)
+
+	clientset := fake.NewClientset(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            nodeName,
+			ResourceVersion: "1",
+			Labels:          map[string]string{label: "true"},
+		},
+	})
+	c, err := NewClientFromClientset(context.Background(), clientset, logrus.New(), WithForceFieldOwnership(true))
+	require.NoError(t, err)
+
+	snapshot, err := c.GetNodeSnapshot(nodeName)
+	require.NoError(t, err)
+	require.Equal(t, "true", snapshot.Labels[label])
+
+	// The node is modified concurrently
+	node, err := c.GetNode(nodeName)
+	require.NoError(t, err)
+	node.ResourceVersion = "2"
+	node.Spec.Unschedulable = true
+	_, err = clientset.CoreV1().Nodes().Update(context.Background(), node, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	_, err = c.UpdateNodeMetadataAt(snapshot, map[string]string{label: "paused-for-driver-upgrade"}, nil)
+	require.ErrorIs(t, err, ErrNodeModified)
+
+	snapshot, err = c.GetNodeSnapshot(nodeName)
+	require.NoError(t, err)
+	require.True(t, snapshot.Unschedulable)
+	updated, err := c.UpdateNodeMetadataAt(snapshot, map[string]string{label: "paused-for-driver-upgrade"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "paused-for-driver-upgrade", updated.Labels[label])
+	require.True(t, updated.Unschedulable)
+}
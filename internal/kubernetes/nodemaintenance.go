@@ -0,0 +1,140 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// NodeMaintenanceResource is the cluster-scoped NodeMaintenance custom resource of the medik8s
+// Node Maintenance Operator, which cordons and drains the node a NodeMaintenance is created
+// for and uncordons it when the NodeMaintenance is deleted
+var NodeMaintenanceResource = schema.GroupVersionResource{
+	Group:    "nodemaintenance.medik8s.io",
+	Version:  "v1beta1",
+	Resource: "nodemaintenances",
+}
+
+const (
+	nodeMaintenanceKind = "NodeMaintenance"
+
+	// Phases of a NodeMaintenance reported by the Node Maintenance Operator
+	nodeMaintenancePhaseSucceeded = "Succeeded"
+	nodeMaintenancePhaseFailed    = "Failed"
+)
+
+// RequestNodeMaintenance creates a NodeMaintenance for the node, unless it already exists, and
+// blocks until the Node Maintenance Operator reports the node as drained. A zero timeout waits
+// indefinitely.
+func (c *Client) RequestNodeMaintenance(name, nodeName, reason string, timeout time.Duration) error {
+	if c.dynamicClient == nil {
+		return fmt.Errorf("no dynamic client configured for NodeMaintenance resources")
+	}
+	nodeMaintenances := c.dynamicClient.Resource(NodeMaintenanceResource)
+
+	nodeMaintenance := &unstructured.Unstructured{}
+	nodeMaintenance.SetAPIVersion(NodeMaintenanceResource.GroupVersion().String())
+	nodeMaintenance.SetKind(nodeMaintenanceKind)
+	nodeMaintenance.SetName(name)
+	nodeMaintenance.SetLabels(map[string]string{"app.kubernetes.io/managed-by": FieldManager})
+	if err := unstructured.SetNestedField(nodeMaintenance.Object, nodeName, "spec", "nodeName"); err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(nodeMaintenance.Object, reason, "spec", "reason"); err != nil {
+		return err
+	}
+
+	c.log.Infof("Requesting the maintenance of node %s through NodeMaintenance %s", nodeName, name)
+	err := c.retry("create NodeMaintenance "+name, func(ctx context.Context) error {
+		_, err := nodeMaintenances.Create(ctx, nodeMaintenance, metav1.CreateOptions{FieldManager: FieldManager})
+		return err
+	})
+	switch {
+	case apierrors.IsAlreadyExists(err):
+		c.log.Infof("NodeMaintenance %s already exists", name)
+	case err != nil:
+		return fmt.Errorf("failed to create NodeMaintenance %s: %w", name, err)
+	}
+
+	ctx := c.ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var lastError string
+	err = wait.PollUntilContextCancel(ctx, c.pollInterval, true, func(ctx context.Context) (bool, error) {
+		current, err := nodeMaintenances.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if isRetryable(err) {
+				c.log.Warnf("Failed to get NodeMaintenance %s, retrying: %v", name, err)
+				return false, nil
+			}
+			return false, err
+		}
+		if target, _, _ := unstructured.NestedString(current.Object, "spec", "nodeName"); target != nodeName {
+			return false, fmt.Errorf("NodeMaintenance %s targets node %q instead of %s", name, target, nodeName)
+		}
+
+		phase, _, _ := unstructured.NestedString(current.Object, "status", "phase")
+		lastError, _, _ = unstructured.NestedString(current.Object, "status", "lastError")
+		switch phase {
+		case nodeMaintenancePhaseSucceeded:
+			return true, nil
+		case nodeMaintenancePhaseFailed:
+			return false, fmt.Errorf("NodeMaintenance %s failed: %s", name, lastError)
+		}
+		c.log.Infof("Waiting for node %s to be drained by NodeMaintenance %s (phase %q)", nodeName, name, phase)
+		return false, nil
+	})
+	if err != nil {
+		if lastError != "" && wait.Interrupted(err) {
+			return fmt.Errorf("NodeMaintenance %s did not complete: %w (last error: %s)", name, err, lastError)
+		}
+		return fmt.Errorf("NodeMaintenance %s did not complete: %w", name, err)
+	}
+	c.log.Infof("Node %s drained by NodeMaintenance %s", nodeName, name)
+	return nil
+}
+
+// DeleteNodeMaintenance deletes the NodeMaintenance, which has the Node Maintenance Operator
+// end the maintenance of its node. A missing NodeMaintenance is not an error.
+func (c *Client) DeleteNodeMaintenance(name string) error {
+	if c.dynamicClient == nil {
+		return fmt.Errorf("no dynamic client configured for NodeMaintenance resources")
+	}
+	err := c.retry("delete NodeMaintenance "+name, func(ctx context.Context) error {
+		return c.dynamicClient.Resource(NodeMaintenanceResource).Delete(ctx, name, metav1.DeleteOptions{})
+	})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete NodeMaintenance %s: %w", name, err)
+	}
+	c.log.Infof("Deleted NodeMaintenance %s", name)
+	return nil
+}
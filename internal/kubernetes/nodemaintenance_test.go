@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestNodeMaintenance(t *testing.T) {
+	const (
+		nodeName = "gpu-node"
+		name     = "nvidia-driver-upgrade-gpu-node"
+	)
+
+	testCases := []struct {
+		description string
+		// status is set on the NodeMaintenance by the simulated Node Maintenance Operator
+		status        map[string]interface{}
+		expectedError bool
+	}{
+		{
+			description: "drained",
+			status:      map[string]interface{}{"phase": "Succeeded"},
+		},
+		{
+			description:   "failed",
+			status:        map[string]interface{}{"phase": "Failed", "lastError": "node not found"},
+			expectedError: true,
+		},
+		{
+			description:   "timeout",
+			status:        map[string]interface{}{"phase": "Running", "lastError": "cannot evict pod"},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{NodeMaintenanceResource: "NodeMaintenanceList"})
+			dynamicClient.PrependReactor("create", "nodemaintenances", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				obj := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+				require.NoError(t, unstructured.SetNestedMap(obj.Object, tc.status, "status"))
+				return false, nil, nil
+			})
+
+			c, err := NewClientFromClientset(context.Background(), fake.NewClientset(), logrus.New(),
+				WithDynamicClient(dynamicClient), WithPollInterval(10*time.Millisecond))
+			require.NoError(t, err)
+
+			err = c.RequestNodeMaintenance(name, nodeName, "driver upgrade", 100*time.Millisecond)
+			if tc.expectedError {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.status["lastError"])
+			} else {
+				require.NoError(t, err)
+			}
+
+			nodeMaintenance, err := dynamicClient.Resource(NodeMaintenanceResource).Get(context.Background(), name, metav1.GetOptions{})
+			require.NoError(t, err)
+			target, _, _ := unstructured.NestedString(nodeMaintenance.Object, "spec", "nodeName")
+			require.Equal(t, nodeName, target)
+
+			// The NodeMaintenance is reused when requested again, e.g. after a restart
+			if !tc.expectedError {
+				require.NoError(t, c.RequestNodeMaintenance(name, nodeName, "driver upgrade", 100*time.Millisecond))
+			}
+
+			require.NoError(t, c.DeleteNodeMaintenance(name))
+			_, err = dynamicClient.Resource(NodeMaintenanceResource).Get(context.Background(), name, metav1.GetOptions{})
+			require.True(t, apierrors.IsNotFound(err))
+			require.NoError(t, c.DeleteNodeMaintenance(name))
+		})
+	}
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// DefaultRetryBackoff is the backoff between the attempts of a request to the API server
+// failing with a retryable error
+var DefaultRetryBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2.0,
+	Jitter:   0.2,
+	Steps:    7,
+}
+
+// errFieldOwnershipConflict marks server-side apply conflicts driver-manager is not allowed to
+// resolve, as opposed to conflicting updates which succeed when retried
+var errFieldOwnershipConflict = errors.New("fields are owned by another field manager")
+
+// isRetryable reports whether a failed request to the API server may succeed when retried:
+// throttled requests, server errors, timeouts and conflicting updates are retried, while
+// requests which are forbidden, invalid or target a missing object fail fast.
+func isRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, errFieldOwnershipConflict) || errors.Is(err, ErrNodeModified) {
+		return false
+	}
+
+	switch {
+	case apierrors.IsForbidden(err), apierrors.IsUnauthorized(err), apierrors.IsNotFound(err),
+		apierrors.IsInvalid(err), apierrors.IsBadRequest(err), apierrors.IsMethodNotSupported(err),
+		apierrors.IsAlreadyExists(err), apierrors.IsGone(err), apierrors.IsRequestEntityTooLargeError(err):
+		return false
+	case apierrors.IsTooManyRequests(err), apierrors.IsServerTimeout(err), apierrors.IsTimeout(err),
+		apierrors.IsConflict(err), apierrors.IsInternalError(err), apierrors.IsServiceUnavailable(err),
+		apierrors.IsUnexpectedServerError(err):
+		return true
+	}
+
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		return status.Status().Code >= http.StatusInternalServerError
+	}
+
+	// The request did not get a response from the API server
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return utilnet.IsConnectionRefused(err) || utilnet.IsConnectionReset(err) || utilnet.IsProbableEOF(err)
+}
+
+// retry performs a request to the API server until it succeeds, fails with an error which is
+// not retryable, the retry backoff is exhausted or the client context is done. The delay
+// before the next attempt is the one suggested by the API server in a Retry-After header if
+// longer than the backoff. The error of the last attempt is returned.
+func (c *Client) retry(operation string, request func(ctx context.Context) error) error {
+	backoff := c.retryBackoff
+	for {
+		err := request(c.ctx)
+		if err == nil || !isRetryable(err) || backoff.Steps <= 1 {
+			return err
+		}
+
+		delay := backoff.Step()
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok && time.Duration(seconds)*time.Second > delay {
+			delay = time.Duration(seconds) * time.Second
+		}
+		c.log.Warnf("Failed to %s, retrying in %s: %v", operation, delay.Round(time.Millisecond), err)
+		if c.sleep(delay) != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestIsRetryable(t *testing.T) {
+	nodes := schema.GroupResource{Resource: "nodes"}
+
+	testCases := []struct {
+		description string
+		err         error
+		expected    bool
+	}{
+		{"too many requests", apierrors.NewTooManyRequests("throttled", 1), true},
+		{"internal error", apierrors.NewInternalError(errors.New("etcd")), true},
+		{"service unavailable", apierrors.NewServiceUnavailable("unavailable"), true},
+		{"server timeout", apierrors.NewServerTimeout(nodes, "get", 1), true},
+		{"gateway timeout", apierrors.NewTimeoutError("timeout", 1), true},
+		{"conflicting update", apierrors.NewConflict(nodes, "gpu-node", errors.New("modified")), true},
+		{"wrapped server error", fmt.Errorf("failed to get node: %w", apierrors.NewInternalError(errors.New("etcd"))), true},
+		{"connection refused", syscall.ECONNREFUSED, true},
+		{"forbidden", apierrors.NewForbidden(nodes, "gpu-node", errors.New("rbac")), false},
+		{"unauthorized", apierrors.NewUnauthorized("token expired"), false},
+		{"not found", apierrors.NewNotFound(nodes, "gpu-node"), false},
+		{"invalid", apierrors.NewInvalid(schema.GroupKind{Kind: "Node"}, "gpu-node", field.ErrorList{}), false},
+		{"bad request", apierrors.NewBadRequest("bad"), false},
+		{"field ownership conflict", fmt.Errorf("%w: %w", errFieldOwnershipConflict, apierrors.NewConflict(nodes, "gpu-node", errors.New("owned"))), false},
+		{"context cancelled", context.Canceled, false},
+		{"other error", errors.New("other"), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.expected, isRetryable(tc.err))
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	const nodeName = "gpu-node"
+	nodes := schema.GroupResource{Resource: "nodes"}
+
+	testCases := []struct {
+		description      string
+		errs             []error
+		expectedAttempts int
+		expectedError    bool
+		minDuration      time.Duration
+	}{
+		{
+			description:      "transient errors",
+			errs:             []error{apierrors.NewServiceUnavailable("unavailable"), apierrors.NewInternalError(errors.New("etcd"))},
+			expectedAttempts: 3,
+		},
+		{
+			description:      "retry after",
+			errs:             []error{apierrors.NewTooManyRequests("throttled", 1)},
+			expectedAttempts: 2,
+			minDuration:      time.Second,
+		},
+		{
+			description:      "forbidden",
+			errs:             []error{apierrors.NewForbidden(nodes, nodeName, errors.New("rbac"))},
+			expectedAttempts: 1,
+			expectedError:    true,
+		},
+		{
+			description: "backoff exhausted",
+			errs: []error{
+				apierrors.NewServiceUnavailable("unavailable"),
+				apierrors.NewServiceUnavailable("unavailable"),
+				apierrors.NewServiceUnavailable("unavailable"),
+				apierrors.NewServiceUnavailable("unavailable"),
+			},
+			expectedAttempts: 3,
+			expectedError:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			clientset := fake.NewClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}})
+			attempts := 0
+			clientset.PrependReactor("get", "nodes", func(k8stesting.Action) (bool, runtime.Object, error) {
+				attempts++
+				if attempts > len(tc.errs) {
+					return false, nil, nil
+				}
+				return true, nil, tc.errs[attempts-1]
+			})
+
+			backoff := wait.Backoff{Duration: 10 * time.Millisecond, Factor: 1, Steps: 3}
+			c, err := NewClientFromClientset(context.Background(), clientset, logrus.New(), WithRetryBackoff(backoff))
+			require.NoError(t, err)
+
+			start := time.Now()
+			node, err := c.GetNode(nodeName)
+			require.Equal(t, tc.expectedAttempts, attempts)
+			require.GreaterOrEqual(t, time.Since(start), tc.minDuration)
+			if tc.expectedError {
+				require.Error(t, err)
+				require.ErrorIs(t, err, tc.errs[attempts-1])
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, nodeName, node.Name)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package linuxutils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const nvidiaDevicesPath = "/dev/nvidia"
+
+// podUIDPattern matches the pod UID in the cgroup path of a container process, for both the
+// cgroupfs ("/kubepods/burstable/pod<uid>/...") and the systemd
+// ("kubepods-burstable-pod<uid_with_underscores>.slice") cgroup drivers.
+var podUIDPattern = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})`)
+
+// GPUProcess is a process holding an NVIDIA device node open
+type GPUProcess struct {
+	PID int
+	// PodUID is the UID of the Kubernetes pod running the process, if any
+	PodUID string
+}
+
+// ListGPUProcesses scans the processes visible in the procfs mounted at procDir for open NVIDIA
+// device nodes. Seeing host processes requires running in the host PID namespace.
+func ListGPUProcesses(procDir string) ([]GPUProcess, error) {
+	entries, err := os.ReadDir(procDir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", procDir, err)
+	}
+
+	var processes []GPUProcess
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		// Processes may exit or be inaccessible while scanning; skip them
+		if !holdsNvidiaDevice(filepath.Join(procDir, entry.Name(), "fd")) {
+			continue
+		}
+		processes = append(processes, GPUProcess{
+			PID:    pid,
+			PodUID: readPodUID(filepath.Join(procDir, entry.Name(), "cgroup")),
+		})
+	}
+	return processes, nil
+}
+
+func holdsNvidiaDevice(fdDir string) bool {
+	fds, err := os.ReadDir(fdDir)
+	if err != nil {
+		return false
+	}
+	for _, fd := range fds {
+		target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+		if err != nil {
+			continue
+		}
+		if strings.HasPrefix(target, nvidiaDevicesPath) {
+			return true
+		}
+	}
+	return false
+}
+
+func readPodUID(cgroupFile string) string {
+	data, err := os.ReadFile(cgroupFile)
+	if err != nil {
+		return ""
+	}
+	match := podUIDPattern.FindStringSubmatch(string(data))
+	if match == nil {
+		return ""
+	}
+	return strings.ReplaceAll(match[1], "_", "-")
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package linuxutils
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListGPUProcesses(t *testing.T) {
+	const podUID = "3f9c5b1e-0c4e-4e8a-9d6f-2b7a1c8e5d40"
+
+	testCases := []struct {
+		description string
+		// fds are the targets of the file descriptors of the process. A nil list leaves out the
+		// fd directory, as for a process which exited during the scan.
+		fds []string
+		// cgroup is the content of the cgroup file of the process. noCgroup leaves the file out.
+		cgroup   string
+		noCgroup bool
+		expected []GPUProcess
+	}{
+		{
+			description: "cgroup v2 with the cgroupfs driver",
+			fds:         []string{"/dev/null", "/dev/nvidia0"},
+			cgroup:      "0::/kubepods/burstable/pod" + podUID + "/5b2e0c9d8a71\n",
+			expected:    []GPUProcess{{PID: 1234, PodUID: podUID}},
+		},
+		{
+			description: "cgroup v2 with the systemd driver",
+			fds:         []string{"/dev/nvidiactl"},
+			cgroup:      "0::/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod3f9c5b1e_0c4e_4e8a_9d6f_2b7a1c8e5d40.slice/cri-containerd-5b2e0c9d8a71.scope\n",
+			expected:    []GPUProcess{{PID: 1234, PodUID: podUID}},
+		},
+		{
+			description: "cgroup v1 with the cgroupfs driver",
+			fds:         []string{"/dev/nvidia-uvm"},
+			cgroup: "12:memory:/kubepods/besteffort/pod" + podUID + "/5b2e0c9d8a71\n" +
+				"11:devices:/kubepods/besteffort/pod" + podUID + "/5b2e0c9d8a71\n" +
+				"0::/\n",
+			expected: []GPUProcess{{PID: 1234, PodUID: podUID}},
+		},
+		{
+			description: "cgroup v1 with the systemd driver",
+			fds:         []string{"/dev/nvidia0"},
+			cgroup: "12:memory:/kubepods.slice/kubepods-pod3f9c5b1e_0c4e_4e8a_9d6f_2b7a1c8e5d40.slice/docker-5b2e0c9d8a71.scope\n" +
+				"1:name=systemd:/kubepods.slice/kubepods-pod3f9c5b1e_0c4e_4e8a_9d6f_2b7a1c8e5d40.slice/docker-5b2e0c9d8a71.scope\n",
+			expected: []GPUProcess{{PID: 1234, PodUID: podUID}},
+		},
+		{
+			description: "host process",
+			fds:         []string{"/dev/nvidia0"},
+			cgroup:      "0::/system.slice/nvidia-persistenced.service\n",
+			expected:    []GPUProcess{{PID: 1234}},
+		},
+		{
+			description: "unreadable cgroup",
+			fds:         []string{"/dev/nvidia0"},
+			noCgroup:    true,
+			expected:    []GPUProcess{{PID: 1234}},
+		},
+		{
+			description: "no NVIDIA device node open",
+			fds:         []string{"/dev/null", "socket:[4242]"},
+			cgroup:      "0::/kubepods/burstable/pod" + podUID + "/5b2e0c9d8a71\n",
+		},
+		{
+			description: "process exited during the scan",
+			cgroup:      "0::/kubepods/burstable/pod" + podUID + "/5b2e0c9d8a71\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			procDir := t.TempDir()
+			pidDir := filepath.Join(procDir, "1234")
+			require.NoError(t, os.Mkdir(pidDir, 0755))
+			if tc.fds != nil {
+				fdDir := filepath.Join(pidDir, "fd")
+				require.NoError(t, os.Mkdir(fdDir, 0755))
+				for i, target := range tc.fds {
+					require.NoError(t, os.Symlink(target, filepath.Join(fdDir, strconv.Itoa(i))))
+				}
+			}
+			if !tc.noCgroup {
+				require.NoError(t, os.WriteFile(filepath.Join(pidDir, "cgroup"), []byte(tc.cgroup), 0644))
+			}
+			// Entries which are not processes are ignored
+			require.NoError(t, os.Mkdir(filepath.Join(procDir, "sys"), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(procDir, "uptime"), []byte("42.00 84.00\n"), 0644))
+
+			processes, err := ListGPUProcesses(procDir)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, processes)
+		})
+	}
+}
+
+func TestListGPUProcessesMissingProcDir(t *testing.T) {
+	_, err := ListGPUProcesses(filepath.Join(t.TempDir(), "proc"))
+	require.Error(t, err)
+}
@@ -29,13 +29,15 @@ import (
 )
 
 const (
-	procModules = "/proc/modules"
+	procRoot    = "/proc"
+	procModules = "modules"
 )
 
 type KernelModules struct {
 	log *logrus.Logger
 
-	root string
+	root     string
+	procRoot string
 }
 
 func NewKernelModules(log *logrus.Logger, options ...func(modules *KernelModules)) *KernelModules {
@@ -48,6 +50,9 @@ func NewKernelModules(log *logrus.Logger, options ...func(modules *KernelModules
 	if km.root == "" {
 		km.root = "/"
 	}
+	if km.procRoot == "" {
+		km.procRoot = filepath.Join(km.root, procRoot)
+	}
 	return km
 }
 
@@ -57,11 +62,27 @@ func WithRoot(root string) func(modules *KernelModules) {
 	}
 }
 
-func (km *KernelModules) List(searchKey string) error {
-	modsFilePath := filepath.Join(km.root, procModules)
+// WithProcRoot sets the path to the procfs, which defaults to the proc directory under the root
+func WithProcRoot(procRoot string) func(modules *KernelModules) {
+	return func(km *KernelModules) {
+		km.procRoot = procRoot
+	}
+}
+
+// Module describes a loaded kernel module
+type Module struct {
+	Name     string   `json:"name"`
+	Size     int      `json:"size"`
+	RefCount int      `json:"refCount"`
+	UsedBy   []string `json:"usedBy,omitempty"`
+}
+
+// Get returns the loaded kernel modules whose /proc/modules entry contains the search key
+func (km *KernelModules) Get(searchKey string) ([]Module, error) {
+	modsFilePath := filepath.Join(km.procRoot, procModules)
 	file, err := os.Open(modsFilePath)
 	if err != nil {
-		return fmt.Errorf("error opening file %s: %w", modsFilePath, err)
+		return nil, fmt.Errorf("error opening file %s: %w", modsFilePath, err)
 	}
 	defer func(file *os.File) {
 		err := file.Close()
@@ -70,9 +91,8 @@ func (km *KernelModules) List(searchKey string) error {
 		}
 	}(file)
 
+	var modules []Module
 	scanner := bufio.NewScanner(file)
-	km.log.Infof("%-20s %-10s %-15s %s\n", "Module", "Size", "Ref Count", "Used by") // Header
-
 	for scanner.Scan() {
 		line := scanner.Text()
 
@@ -97,16 +117,44 @@ func (km *KernelModules) List(searchKey string) error {
 				continue
 			}
 
-			usedBy := fields[3]
+			// The holders are listed comma-separated with a trailing comma, or "-" if none
+			var usedBy []string
+			for _, holder := range strings.Split(fields[3], ",") {
+				if holder != "" && holder != "-" {
+					usedBy = append(usedBy, holder)
+				}
+			}
 
-			km.log.Printf("%-20s %-10d %-15d %s\n", name, size, refCnt, usedBy)
+			modules = append(modules, Module{
+				Name:     name,
+				Size:     size,
+				RefCount: refCnt,
+				UsedBy:   usedBy,
+			})
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		km.log.Errorf("Error reading /proc/modules: %v\n", err)
+		return nil, fmt.Errorf("error reading %s: %w", modsFilePath, err)
+	}
+	return modules, nil
+}
+
+func (km *KernelModules) List(searchKey string) error {
+	modules, err := km.Get(searchKey)
+	if err != nil {
+		km.log.Errorf("%v", err)
 		return err
 	}
+
+	km.log.Infof("%-20s %-10s %-15s %s\n", "Module", "Size", "Ref Count", "Used by") // Header
+	for _, m := range modules {
+		usedBy := "-"
+		if len(m.UsedBy) > 0 {
+			usedBy = strings.Join(m.UsedBy, ",") + ","
+		}
+		km.log.Printf("%-20s %-10d %-15d %s\n", m.Name, m.Size, m.RefCount, usedBy)
+	}
 	return nil
 }
 
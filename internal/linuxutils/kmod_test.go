@@ -0,0 +1,81 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package linuxutils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKernelModulesGet(t *testing.T) {
+	const modules = `nvidia_uvm 1961984 0 - Live 0x0000000000000000 (PO)
+nvidia_drm 122880 2 - Live 0x0000000000000000 (PO)
+nvidia_modeset 1638400 1 nvidia_drm, Live 0x0000000000000000 (PO)
+nvidia 89210880 19 nvidia_uvm,nvidia_modeset, Live 0x0000000000000000 (PO)
+ext4 1019904 1 - Live 0x0000000000000000
+nvidia_peermem invalid 0 - Live 0x0000000000000000 (O)
+truncated 4096
+`
+	nvidiaModules := []Module{
+		{Name: "nvidia_uvm", Size: 1961984},
+		{Name: "nvidia_drm", Size: 122880, RefCount: 2},
+		{Name: "nvidia_modeset", Size: 1638400, RefCount: 1, UsedBy: []string{"nvidia_drm"}},
+		{Name: "nvidia", Size: 89210880, RefCount: 19, UsedBy: []string{"nvidia_uvm", "nvidia_modeset"}},
+	}
+
+	testCases := []struct {
+		description string
+		searchKey   string
+		expected    []Module
+	}{
+		{
+			description: "NVIDIA modules",
+			searchKey:   "nvidia",
+			expected:    nvidiaModules,
+		},
+		{
+			description: "all modules",
+			expected:    append(nvidiaModules, Module{Name: "ext4", Size: 1019904, RefCount: 1}),
+		},
+		{
+			description: "module not loaded",
+			searchKey:   "nouveau",
+		},
+	}
+
+	procRoot := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(procRoot, "modules"), []byte(modules), 0644))
+	km := NewKernelModules(logrus.New(), WithProcRoot(procRoot))
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			found, err := km.Get(tc.searchKey)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, found)
+		})
+	}
+}
+
+func TestKernelModulesGetMissingProcRoot(t *testing.T) {
+	km := NewKernelModules(logrus.New(), WithProcRoot(filepath.Join(t.TempDir(), "proc")))
+	_, err := km.Get("nvidia")
+	require.Error(t, err)
+}
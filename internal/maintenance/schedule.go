@@ -0,0 +1,155 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maintenance
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schedule is a parsed standard five-field cron expression:
+// minute, hour, day of month, month and day of week.
+type schedule struct {
+	minutes     map[int]bool
+	hours       map[int]bool
+	daysOfMonth map[int]bool
+	months      map[int]bool
+	daysOfWeek  map[int]bool
+
+	// Following cron, when both the day of month and the day of week are restricted,
+	// a time matches if either of them matches.
+	domRestricted bool
+	dowRestricted bool
+}
+
+type fieldBounds struct {
+	name     string
+	min, max int
+}
+
+var (
+	minuteBounds     = fieldBounds{"minute", 0, 59}
+	hourBounds       = fieldBounds{"hour", 0, 23}
+	dayOfMonthBounds = fieldBounds{"day of month", 1, 31}
+	monthBounds      = fieldBounds{"month", 1, 12}
+	// Both 0 and 7 denote Sunday
+	dayOfWeekBounds = fieldBounds{"day of week", 0, 7}
+)
+
+// parseSchedule parses a five-field cron expression. Each field accepts "*", single values,
+// ranges ("1-5"), steps ("*/15", "0-30/10") and comma-separated lists of those.
+func parseSchedule(expr string) (*schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields in cron expression %q, got %d", expr, len(fields))
+	}
+
+	s := &schedule{
+		domRestricted: fields[2] != "*",
+		dowRestricted: fields[4] != "*",
+	}
+
+	var err error
+	if s.minutes, err = parseField(fields[0], minuteBounds); err != nil {
+		return nil, err
+	}
+	if s.hours, err = parseField(fields[1], hourBounds); err != nil {
+		return nil, err
+	}
+	if s.daysOfMonth, err = parseField(fields[2], dayOfMonthBounds); err != nil {
+		return nil, err
+	}
+	if s.months, err = parseField(fields[3], monthBounds); err != nil {
+		return nil, err
+	}
+	if s.daysOfWeek, err = parseField(fields[4], dayOfWeekBounds); err != nil {
+		return nil, err
+	}
+	if s.daysOfWeek[7] {
+		s.daysOfWeek[0] = true
+	}
+
+	return s, nil
+}
+
+func parseField(field string, bounds fieldBounds) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step %q in %s field %q", stepPart, bounds.name, field)
+			}
+		}
+
+		start, end := bounds.min, bounds.max
+		if rangePart != "*" {
+			low, high, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = parseValue(low, bounds); err != nil {
+				return nil, err
+			}
+			end = start
+			if isRange {
+				if end, err = parseValue(high, bounds); err != nil {
+					return nil, err
+				}
+			} else if hasStep {
+				end = bounds.max
+			}
+			if start > end {
+				return nil, fmt.Errorf("invalid range %q in %s field", rangePart, bounds.name)
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+func parseValue(value string, bounds fieldBounds) (int, error) {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s field", value, bounds.name)
+	}
+	if v < bounds.min || v > bounds.max {
+		return 0, fmt.Errorf("value %d out of range [%d-%d] in %s field", v, bounds.min, bounds.max, bounds.name)
+	}
+	return v, nil
+}
+
+// matches reports whether the schedule fires at the minute of t
+func (s *schedule) matches(t time.Time) bool {
+	if !s.minutes[t.Minute()] || !s.hours[t.Hour()] || !s.months[int(t.Month())] {
+		return false
+	}
+
+	domMatch := s.daysOfMonth[t.Day()]
+	dowMatch := s.daysOfWeek[int(t.Weekday())]
+	if s.domRestricted && s.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maintenance
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// maxWindowDuration bounds the duration of a window, which also bounds the search for
+	// the start of the window containing a given time
+	maxWindowDuration = 7 * 24 * time.Hour
+	// maxNextSearch bounds the search for the start of the next window
+	maxNextSearch = 366 * 24 * time.Hour
+)
+
+// Window is a recurring maintenance window which opens whenever its cron schedule fires and
+// stays open for a fixed duration
+type Window struct {
+	Schedule string
+	Location *time.Location
+	Duration time.Duration
+
+	schedule *schedule
+}
+
+// Config is the serialized form of a maintenance window
+type Config struct {
+	// Schedule is a five-field cron expression for the start of each window
+	Schedule string `json:"schedule"`
+	// Timezone is the IANA name of the timezone the schedule is evaluated in; UTC if empty
+	Timezone string `json:"timezone,omitempty"`
+	// Duration is the length of each window, e.g. "4h"
+	Duration string `json:"duration"`
+}
+
+// NewWindow returns the maintenance window described by the given configuration
+func NewWindow(config Config) (*Window, error) {
+	s, err := parseSchedule(config.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid maintenance window schedule: %w", err)
+	}
+
+	location := time.UTC
+	if config.Timezone != "" {
+		location, err = time.LoadLocation(config.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance window timezone: %w", err)
+		}
+	}
+
+	duration, err := time.ParseDuration(config.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("invalid maintenance window duration: %w", err)
+	}
+	if duration < time.Minute || duration > maxWindowDuration {
+		return nil, fmt.Errorf("maintenance window duration %s must be between 1m and %s", duration, maxWindowDuration)
+	}
+
+	return &Window{
+		Schedule: config.Schedule,
+		Location: location,
+		Duration: duration,
+		schedule: s,
+	}, nil
+}
+
+// Contains reports whether t falls within an occurrence of the window
+func (w *Window) Contains(t time.Time) bool {
+	t = t.In(w.Location)
+	start := t.Truncate(time.Minute)
+	for ; t.Sub(start) < w.Duration; start = start.Add(-time.Minute) {
+		if w.schedule.matches(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the start of the next occurrence of the window after t, or the zero time if
+// the schedule does not fire within a year
+func (w *Window) Next(t time.Time) time.Time {
+	t = t.In(w.Location)
+	start := t.Truncate(time.Minute).Add(time.Minute)
+	for end := t.Add(maxNextSearch); start.Before(end); start = start.Add(time.Minute) {
+		if w.schedule.matches(start) {
+			return start
+		}
+	}
+	return time.Time{}
+}
+
+// String returns a human-readable description of the window
+func (w *Window) String() string {
+	return fmt.Sprintf("%q (%s) for %s", w.Schedule, w.Location, w.Duration)
+}
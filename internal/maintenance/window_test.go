@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWindowErrors(t *testing.T) {
+	testCases := []struct {
+		description string
+		config      Config
+	}{
+		{"too few fields", Config{Schedule: "0 2 * *", Duration: "1h"}},
+		{"minute out of range", Config{Schedule: "60 2 * * *", Duration: "1h"}},
+		{"inverted range", Config{Schedule: "0 5-2 * * *", Duration: "1h"}},
+		{"zero step", Config{Schedule: "*/0 * * * *", Duration: "1h"}},
+		{"unknown timezone", Config{Schedule: "0 2 * * *", Timezone: "Mars/Olympus", Duration: "1h"}},
+		{"invalid duration", Config{Schedule: "0 2 * * *", Duration: "forever"}},
+		{"duration too short", Config{Schedule: "0 2 * * *", Duration: "30s"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			_, err := NewWindow(tc.config)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestWindowContains(t *testing.T) {
+	// Saturdays and Sundays from 02:00 to 06:00 Berlin time
+	window, err := NewWindow(Config{Schedule: "0 2 * * 6,0", Timezone: "Europe/Berlin", Duration: "4h"})
+	require.NoError(t, err)
+	berlin := window.Location
+
+	testCases := []struct {
+		description      string
+		time             time.Time
+		expectedContains bool
+	}{
+		{"start of window", time.Date(2026, 10, 17, 2, 0, 0, 0, berlin), true},
+		{"inside window", time.Date(2026, 10, 18, 5, 59, 59, 0, berlin), true},
+		{"end of window", time.Date(2026, 10, 17, 6, 0, 0, 0, berlin), false},
+		{"before window", time.Date(2026, 10, 17, 1, 59, 0, 0, berlin), false},
+		{"weekday", time.Date(2026, 10, 19, 3, 0, 0, 0, berlin), false},
+		{"inside window in UTC", time.Date(2026, 10, 17, 1, 30, 0, 0, time.UTC), true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.expectedContains, window.Contains(tc.time))
+		})
+	}
+}
+
+func TestWindowNext(t *testing.T) {
+	window, err := NewWindow(Config{Schedule: "30 1 1-7 * 1", Duration: "2h"})
+	require.NoError(t, err)
+
+	// Both the day of month and the day of week are restricted, so either matches
+	next := window.Next(time.Date(2026, 10, 7, 12, 0, 0, 0, time.UTC))
+	require.Equal(t, time.Date(2026, 10, 12, 1, 30, 0, 0, time.UTC), next)
+
+	window, err = NewWindow(Config{Schedule: "*/20 3 * * *", Duration: "10m"})
+	require.NoError(t, err)
+	next = window.Next(time.Date(2026, 10, 7, 3, 20, 0, 0, time.UTC))
+	require.Equal(t, time.Date(2026, 10, 7, 3, 40, 0, 0, time.UTC), next)
+}